@@ -0,0 +1,44 @@
+package builder
+
+// Auth describes how a request is authenticated. Only one of Basic, Bearer,
+// APIKey and OAuth2 may be given, an empty Auth sends the request without authentication.
+// Values may refer to environment variables using `${ENV:NAME}`.
+type Auth struct {
+	Basic *BasicAuth `json:"basic,omitempty"`
+
+	// Bearer is a token sent in the Authorization header.
+	Bearer string `json:"bearer,omitempty"`
+
+	APIKey *APIKeyAuth `json:"apiKey,omitempty"`
+
+	OAuth2 *OAuth2Auth `json:"oauth2,omitempty"`
+}
+
+// BasicAuth is a username and password sent using HTTP basic authentication.
+type BasicAuth struct {
+	User string `json:"user"`
+	Pass string `json:"pass"`
+}
+
+const (
+	// APIKeyInHeader and APIKeyInQuery are where an API key may be sent.
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+)
+
+// APIKeyAuth is a key sent as either a header or query parameter.
+type APIKeyAuth struct {
+	// In is where the key is sent, either "header" or "query".
+	In    string `json:"in"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// OAuth2Auth acquires a bearer token from an OAuth2 token endpoint using the
+// client credentials grant. The token is reused until it expires.
+type OAuth2Auth struct {
+	TokenURL     string   `json:"tokenUrl"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
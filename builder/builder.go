@@ -1,5 +1,18 @@
 package builder
 
+const (
+	// JSONExtension is the extension of test definition files written in JSON.
+	JSONExtension = ".ac.json"
+
+	// YAMLExtension and YMLExtension are the extensions of test definition
+	// files written in YAML.
+	YAMLExtension = ".ac.yaml"
+	YMLExtension  = ".ac.yml"
+)
+
+// Extensions lists the extension of every supported test definition format.
+var Extensions = []string{JSONExtension, YAMLExtension, YMLExtension}
+
 // Cookie represents a cookie that will be sent to the server for an APITest.
 // This would typically be used as an authentication method.
 type Cookie struct {
@@ -15,6 +28,39 @@ type APITest struct {
 	Endpoint    string      `json:"endpoint"`
 	Request     APIRequest  `json:"request"`
 	Response    APIResponse `json:"response"`
+
+	// Tags are used to select a subset of tests to run.
+	Tags []string `json:"tags,omitempty"`
+
+	// Skip, when non-empty, is the reason the test should not be run.
+	Skip string `json:"skip,omitempty"`
+
+	// Only marks the test as focused. When any test is focused every other
+	// test is skipped.
+	Only bool `json:"only,omitempty"`
+
+	// Session names the cookie session the test belongs to. Cookies set by
+	// the server are stored in the session and sent by every later test in it.
+	Session string `json:"session,omitempty"`
+
+	// ResetSession clears the cookies of the test's session before it is run.
+	ResetSession bool `json:"resetSession,omitempty"`
+
+	// FollowRedirects, when non-nil, overrides whether redirects are followed
+	// for the test. When false the redirect response itself is asserted.
+	FollowRedirects *bool `json:"followRedirects,omitempty"`
+
+	// Retry attempts the test again when the response has one of the given
+	// status codes or no response is received.
+	Retry *Retry `json:"retry,omitempty"`
+
+	// Eventually attempts the test again until every assertion holds, which
+	// allows polling an endpoint until it reaches the expected state.
+	Eventually *Retry `json:"eventually,omitempty"`
+
+	// File is the test definition file the test was parsed from. It is not
+	// part of the test definition itself.
+	File string `json:"-"`
 }
 
 // APIRequest describes the HTTP request that will be sent by api-check while
@@ -25,6 +71,10 @@ type APIRequest struct {
 	QueryParams map[string]string `json:"query-params"`
 	JSON        interface{}       `json:"json,omitempty"`
 	Cookies     []Cookie          `json:"cookies,omitempty"`
+
+	// Auth describes how the request is authenticated, when nil the default
+	// from the config file is used.
+	Auth *Auth `json:"auth,omitempty"`
 }
 
 // JSONType describes the structure of the expected JSON to receive.
@@ -48,9 +98,32 @@ type APIResponse struct {
 	// of what should be received.
 	TypeOf *interface{} `json:"ofType,omitempty"`
 
+	// EmptyBody asserts the server responds without a body, as an empty Body
+	// means the body is not checked.
+	EmptyBody bool `json:"emptyBody,omitempty"`
+
+	// ContentLength describes the length in bytes of the body expected from
+	// the server, as given by the Content-Length header.
+	ContentLength *int64 `json:"contentLength,omitempty"`
+
 	// Describes the headers that are expected to be received from the server.
-	Headers map[string]string `json:"headers"`
+	Headers map[string]HeaderMatcher `json:"headers"`
+
+	// Describes the cookies that are expected to be set by the server.
+	Cookies map[string]CookieAssertion `json:"cookies,omitempty"`
 
 	// Describes the status code expected from the server.
 	StatusCode int `json:"code"`
+
+	// Redirects describes every redirect expected to be followed, in order,
+	// before the response is received.
+	Redirects []RedirectHop `json:"redirects,omitempty"`
+}
+
+// RedirectHop describes a single redirect response.
+type RedirectHop struct {
+	StatusCode int `json:"code"`
+
+	// Location is the expected Location header, it is not checked if empty.
+	Location string `json:"location,omitempty"`
 }
@@ -0,0 +1,27 @@
+package builder
+
+// CookieAssertion describes what is expected of a single cookie set by the
+// server using the Set-Cookie header. Attributes left unset are not checked.
+type CookieAssertion struct {
+	// Absent asserts the cookie is not set at all.
+	Absent bool `json:"absent,omitempty"`
+
+	// Value, when non-nil, is the exact value expected.
+	Value *string `json:"value,omitempty"`
+
+	// Pattern is a regular expression the value must match.
+	Pattern string `json:"pattern,omitempty"`
+
+	HTTPOnly *bool `json:"httpOnly,omitempty"`
+	Secure   *bool `json:"secure,omitempty"`
+
+	// SameSite is one of "Strict", "Lax" or "None", case insensitive.
+	SameSite string `json:"sameSite,omitempty"`
+
+	Path   string `json:"path,omitempty"`
+	Domain string `json:"domain,omitempty"`
+
+	// MaxAge is the expected Max-Age attribute in seconds. A value of 0
+	// asserts the cookie is being deleted.
+	MaxAge *int `json:"maxAge,omitempty"`
+}
@@ -7,10 +7,11 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 const (
-	extension            = ".ac.json" // TODO: extension should be globalized.
 	SkeletonDescription  = "Test Description"
 	SkeletonEndpoint     = "/"
 	SkeletonHostname     = "http://localhost"
@@ -18,17 +19,51 @@ const (
 	SkeletonResponseCode = 200
 )
 
-// CreateSkeletonFile writes a .ac.json skeleton file with the given prefix.
-// Returns the name of the file written and an error if applicable.
-func CreateSkeletonFile(prefix string) (string, error) {
+// Format describes the format a test definition file is written in.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// extension produces the file extension used for the format.
+func (f Format) extension() (string, error) {
+	switch f {
+	case FormatJSON:
+		return JSONExtension, nil
+	case FormatYAML:
+		return YAMLExtension, nil
+	}
+
+	return "", fmt.Errorf("unsupported format: %v", f)
+}
+
+// skeleton produces the contents of a skeleton file in the format.
+func (f Format) skeleton() ([]byte, error) {
+	if f == FormatYAML {
+		return YAMLSkeleton()
+	}
+
+	return JSONSkeleton()
+}
+
+// CreateSkeletonFile writes a skeleton file with the given prefix in the given
+// format. Returns the name of the file written and an error if applicable.
+func CreateSkeletonFile(prefix string, format Format) (string, error) {
 	if len(prefix) == 0 {
 		return "", errors.New("filename cannot be empty")
 	}
 
+	extension, err := format.extension()
+	if err != nil {
+		return "", err
+	}
+
 	filename := prefix
 
-	// If the provided prefix contains the '.ac.json' suffic don't append
-	// an addition '.ac.json' suffix.
+	// If the provided prefix contains the extension suffix don't append
+	// an addition extension suffix.
 	if !strings.HasSuffix(prefix, extension) {
 		filename = filename + extension
 	}
@@ -38,7 +73,7 @@ func CreateSkeletonFile(prefix string) (string, error) {
 		return "", fmt.Errorf("cannot create template file as file %v already exists", filename)
 	}
 
-	contents, err := JSONSkeleton()
+	contents, err := format.skeleton()
 	if err != nil {
 		return "", err
 	}
@@ -63,7 +98,7 @@ func Skeleton() APITest {
 			QueryParams: make(map[string]string),
 		},
 		Response: APIResponse{
-			Headers:    make(map[string]string),
+			Headers:    make(map[string]HeaderMatcher),
 			StatusCode: SkeletonResponseCode,
 		},
 	}
@@ -82,3 +117,31 @@ func JSONSkeleton() ([]byte, error) {
 
 	return d, nil
 }
+
+// blockStyle resets the style of the node and all of its children so they are
+// written in YAML's default block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// YAMLSkeleton returns the byte array representation of an empty API test
+// definition as part of a YAML sequence.
+func YAMLSkeleton() ([]byte, error) {
+	d, err := JSONSkeleton()
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, decoding it into a node keeps the key order of the
+	// JSON skeleton which uses the same field names as the parser.
+	var node yaml.Node
+	if err := yaml.Unmarshal(d, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+
+	return yaml.Marshal(&node)
+}
@@ -5,22 +5,26 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
 var createSkeletonFileTests = []struct {
 	prefix   string
+	format   Format
 	filename string
 	err      error
 }{
-	{"", "", errors.New("filename cannot be empty")}, // Empty prefix should cause a failure to occur.
-	{"unit-test.ac.json", "unit-test.ac.json", nil},  // Empty prefix should cause a failure to occur.
-	{"unit-test", "unit-test.ac.json", nil},          // Empty prefix should cause a failure to occur.
+	{"", FormatJSON, "", errors.New("filename cannot be empty")}, // Empty prefix should cause a failure to occur.
+	{"unit-test.ac.json", FormatJSON, "unit-test.ac.json", nil},  // Empty prefix should cause a failure to occur.
+	{"unit-test", FormatJSON, "unit-test.ac.json", nil},          // Empty prefix should cause a failure to occur.
+	{"unit-test", FormatYAML, "unit-test.ac.yaml", nil},          // YAML files should use the YAML extension.
+	{"unit-test", Format("xml"), "", errors.New("unsupported format: xml")},
 }
 
 func TestCreateSkeletonFile(t *testing.T) {
 	for _, test := range createSkeletonFileTests {
-		filename, err := CreateSkeletonFile(test.prefix)
+		filename, err := CreateSkeletonFile(test.prefix, test.format)
 		if (err != nil && test.err == nil) || (err == nil && test.err != nil) ||
 			(err != nil && err.Error() != test.err.Error()) {
 			t.Errorf("Expected error: %v but received: %v", test.err, err)
@@ -34,13 +38,13 @@ func TestCreateSkeletonFile(t *testing.T) {
 
 		// If we expect the test to succeed check the contents of the file.
 		if test.err == nil {
-			contents, err := ioutil.ReadFile("unit-test.ac.json")
+			contents, err := ioutil.ReadFile(test.filename)
 			if err != nil {
 				t.Errorf("Unable to complete test: %v", err)
 				continue
 			}
 
-			expectedContents, _ := JSONSkeleton()
+			expectedContents, _ := test.format.skeleton()
 			if !reflect.DeepEqual(contents, expectedContents) {
 				t.Errorf("Found mismatching results when comparing skeleton contents")
 			}
@@ -49,3 +53,17 @@ func TestCreateSkeletonFile(t *testing.T) {
 		os.Remove(filename)
 	}
 }
+
+func TestYAMLSkeleton(t *testing.T) {
+	contents, err := YAMLSkeleton()
+	if err != nil {
+		t.Fatalf("Unable to create YAML skeleton: %v", err)
+	}
+
+	// The skeleton should use block style and the same keys as JSON.
+	for _, expected := range []string{"- description: Test Description\n", "  endpoint: /\n", "    code: 200\n"} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf("Expected YAML skeleton to contain %q but received:\n%v", expected, string(contents))
+		}
+	}
+}
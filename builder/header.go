@@ -0,0 +1,111 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// HeaderMatcher describes what is expected of a single response header. In a
+// test definition it is either a string, number or boolean, matching the header
+// exactly, an array of these, matching every value of the header in order, or an
+// object using the `$absent`, `$regex` or `$contains` operators.
+type HeaderMatcher struct {
+	// Value, when non-nil, is the exact value expected.
+	Value *string
+
+	// Values, when non-nil, are every value expected in order.
+	Values []string
+
+	// Absent asserts the header is not sent at all.
+	Absent bool
+
+	// Regex is a regular expression one of the values must match.
+	Regex string
+
+	// Contains is a string one of the values must contain.
+	Contains string
+}
+
+// headerOperators is the object form of a HeaderMatcher.
+type headerOperators struct {
+	Absent   bool   `json:"$absent,omitempty"`
+	Regex    string `json:"$regex,omitempty"`
+	Contains string `json:"$contains,omitempty"`
+}
+
+// HeaderValue creates a HeaderMatcher expecting the exact value given.
+func HeaderValue(value string) HeaderMatcher {
+	return HeaderMatcher{Value: &value}
+}
+
+// UnmarshalJSON decodes the string, array or object form of a HeaderMatcher.
+func (m *HeaderMatcher) UnmarshalJSON(data []byte) error {
+	*m = HeaderMatcher{}
+
+	switch data = bytes.TrimSpace(data); {
+	case bytes.HasPrefix(data, []byte("[")):
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+
+		values := make([]string, len(raw))
+		for i, r := range raw {
+			value, err := headerValue(r)
+			if err != nil {
+				return err
+			}
+			values[i] = value
+		}
+		m.Values = values
+	case bytes.HasPrefix(data, []byte("{")):
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+
+		var ops headerOperators
+		if err := dec.Decode(&ops); err != nil {
+			return fmt.Errorf("invalid header matcher: %v", err)
+		}
+		m.Absent, m.Regex, m.Contains = ops.Absent, ops.Regex, ops.Contains
+	default:
+		value, err := headerValue(data)
+		if err != nil {
+			return err
+		}
+		m.Value = &value
+	}
+
+	return nil
+}
+
+// headerValue decodes a single expected header value. Numbers and booleans are
+// matched as they are written, which allows unquoted values such as
+// `Content-Length: 0` in YAML.
+func headerValue(data []byte) (string, error) {
+	var scalar interface{}
+	if err := json.Unmarshal(data, &scalar); err != nil {
+		return "", err
+	}
+
+	switch value := scalar.(type) {
+	case string:
+		return value, nil
+	case float64, bool:
+		return string(bytes.TrimSpace(data)), nil
+	}
+
+	return "", fmt.Errorf("header matcher must be a string, number, boolean, array or object but found %s", data)
+}
+
+// MarshalJSON encodes the matcher using the simplest form able to describe it.
+func (m HeaderMatcher) MarshalJSON() ([]byte, error) {
+	switch {
+	case m.Value != nil:
+		return json.Marshal(*m.Value)
+	case m.Values != nil:
+		return json.Marshal(m.Values)
+	}
+
+	return json.Marshal(headerOperators{Absent: m.Absent, Regex: m.Regex, Contains: m.Contains})
+}
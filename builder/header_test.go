@@ -0,0 +1,47 @@
+package builder
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestHeaderMatcherJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected HeaderMatcher
+		output   string
+	}{
+		{`"application/json"`, HeaderValue("application/json"), `"application/json"`},
+		{`["a=1", "b=2"]`, HeaderMatcher{Values: []string{"a=1", "b=2"}}, `["a=1","b=2"]`},
+		{`[]`, HeaderMatcher{Values: []string{}}, `[]`},
+		{`0`, HeaderValue("0"), `"0"`},
+		{`true`, HeaderValue("true"), `"true"`},
+		{`[1, "a=1", false]`, HeaderMatcher{Values: []string{"1", "a=1", "false"}}, `["1","a=1","false"]`},
+		{`{"$absent": true}`, HeaderMatcher{Absent: true}, `{"$absent":true}`},
+		{`{"$regex": "^max-age=\\d+$", "$contains": "max-age"}`, HeaderMatcher{Regex: `^max-age=\d+$`, Contains: "max-age"}, `{"$regex":"^max-age=\\d+$","$contains":"max-age"}`},
+	}
+
+	for _, test := range tests {
+		var m HeaderMatcher
+		if err := json.Unmarshal([]byte(test.input), &m); err != nil {
+			t.Errorf("Received unexpected error decoding %v: %v", test.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(m, test.expected) {
+			t.Errorf("Expected %+v but received %+v", test.expected, m)
+		}
+
+		if output, err := json.Marshal(m); err != nil || string(output) != test.output {
+			t.Errorf("Expected %v to be encoded as %v but received %s (%v)", test.input, test.output, output, err)
+		}
+	}
+
+	for _, input := range []string{`null`, `{"$exists": true}`, `[null]`, `[{}]`} {
+		var m HeaderMatcher
+		if err := json.Unmarshal([]byte(input), &m); err == nil {
+			t.Errorf("Expected an error decoding %v", input)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration written in test definitions as a string such as
+// "500ms" or "30s".
+type Duration time.Duration
+
+// UnmarshalJSON decodes a duration string using time.ParseDuration.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"500ms\" but found %s", data)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Retry describes how a test is attempted more than once. Attempts stop once
+// either the number of attempts or the timeout is reached.
+type Retry struct {
+	// Attempts is the most times the test is attempted, including the first.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Interval is how long to wait between attempts.
+	Interval Duration `json:"interval,omitempty"`
+
+	// Timeout is how long after the first attempt to stop attempting.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	// Backoff multiplies the interval after each attempt, 1 keeps it constant.
+	Backoff float64 `json:"backoff,omitempty"`
+
+	// OnStatus lists the status codes of responses which are retried.
+	OnStatus []int `json:"onStatus,omitempty"`
+
+	// OnError retries requests which fail to receive a response, such as when
+	// the connection is refused.
+	OnError bool `json:"onError,omitempty"`
+}
@@ -0,0 +1,24 @@
+package builder
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDurationJSON(t *testing.T) {
+	var d Duration
+	if err := json.Unmarshal([]byte(`"1m30s"`), &d); err != nil || time.Duration(d) != 90*time.Second {
+		t.Errorf("Expected 1m30s but received %v (%v)", time.Duration(d), err)
+	}
+
+	if output, err := json.Marshal(Duration(500 * time.Millisecond)); err != nil || string(output) != `"500ms"` {
+		t.Errorf("Expected \"500ms\" but received %s (%v)", output, err)
+	}
+
+	for _, input := range []string{`500`, `"soon"`} {
+		if err := json.Unmarshal([]byte(input), &d); err == nil {
+			t.Errorf("Expected an error decoding %v", input)
+		}
+	}
+}
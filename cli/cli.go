@@ -2,17 +2,20 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/JonathonGore/api-check/builder"
 	"github.com/JonathonGore/api-check/config"
+	"github.com/JonathonGore/api-check/filter"
+	"github.com/JonathonGore/api-check/loader"
 	"github.com/JonathonGore/api-check/parser"
+	"github.com/JonathonGore/api-check/printer"
 	"github.com/JonathonGore/api-check/suite"
 	"github.com/urfave/cli"
 )
 
 const (
-	confFile         = ".ac.json"
-	defaultVerbosity = true
+	confFile = ".ac.json"
 )
 
 // commandNotFound is executed when the user tries to execute an errorneous command.
@@ -26,7 +29,7 @@ func generateAction(c *cli.Context) error {
 		return cli.NewExitError(fmt.Sprintf("%v %v requires exactly 1 argument", c.App.Name, c.Command.Name), 1)
 	}
 
-	filename, err := builder.CreateSkeletonFile(c.Args().First())
+	filename, err := builder.CreateSkeletonFile(c.Args().First(), builder.Format(c.String("format")))
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
@@ -36,20 +39,47 @@ func generateAction(c *cli.Context) error {
 	return nil
 }
 
-// verifyAction defines the action that is run by incoking `api-check verify <filename>`
+// configFile produces the config file named by the --config flag, defaulting
+// to the config file in the directory named by the --dir flag.
+func configFile(c *cli.Context) string {
+	if c.String("config") != "" {
+		return c.String("config")
+	}
+
+	return config.DefaultPath(c.String("dir"))
+}
+
+// verifyAction defines the action that is run by incoking `api-check verify [filenames...]`.
+// When no filenames are given every test definition in the --dir directory is verified.
 func verifyAction(c *cli.Context) error {
-	if c.NArg() == 0 {
-		return cli.NewExitError(fmt.Sprintf("%v %v requires at least 1 argument", c.App.Name, c.Command.Name), 1)
+	// A config file named by --config must exist, the default one is optional.
+	readConfig := config.New
+	if c.String("config") != "" {
+		readConfig = config.Read
 	}
 
-	conf, err := config.New(config.DefaultConfigFile)
+	conf, err := readConfig(configFile(c))
 	if err != nil {
 		return cli.NewExitError(fmt.Sprintf("error parsing config file: %v", err), 1)
 	}
 
+	files := []string(c.Args())
+	if len(files) == 0 {
+		files, err = loader.New(conf).FindTestDefinitions(c.String("dir"))
+		if errs, ok := err.(loader.Errors); ok && c.Bool("lenient") {
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+		} else if err != nil {
+			return cli.NewExitError(fmt.Sprintf("unable to find test definition files:\n%v", err), 1)
+		}
+	}
+
+	// Unknown fields are most likely typos so verification should fail.
 	p := parser.New(conf)
+	p.Strict(true)
 
-	if _, err := p.Parse([]string(c.Args())); err != nil {
+	if _, err := p.Parse(files); err != nil {
 		return cli.NewExitError(fmt.Sprintf("%v", err), 1)
 	}
 
@@ -57,31 +87,91 @@ func verifyAction(c *cli.Context) error {
 	return nil
 }
 
-// runAction defines the action that is run by invoking `api-check run`
+// runAction defines the action that is run by invoking `api-check run [paths...]`
 func runAction(c *cli.Context) error {
-	suite.Verbose(defaultVerbosity)
-	suite.RunStandalone()
+	suite.RunStandalone(
+		suite.WithReporter(printer.NewTerminal(c.Bool("no-color"))),
+		suite.WithDir(c.String("dir")),
+		suite.WithConfigFile(c.String("config")),
+		suite.WithLenient(c.Bool("lenient")),
+		suite.WithReruns(c.Int("rerun-failures")),
+		suite.WithPaths(c.Args()...),
+		suite.WithFilter(filter.Filter{
+			Tags:        c.StringSlice("tag"),
+			ExcludeTags: c.StringSlice("exclude-tag"),
+			Grep:        c.String("grep"),
+		}),
+	)
 	return nil
 }
 
+// suiteFlags are the flags shared by every command operating on a test suite.
+var suiteFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "dir",
+		Value: ".",
+		Usage: "directory containing the test definitions",
+	},
+	cli.StringFlag{
+		Name:  "config",
+		Usage: "path of the config file, defaults to .ac.json in the test directory",
+	},
+	cli.BoolFlag{
+		Name:  "lenient",
+		Usage: "warn about test definitions that cannot be found instead of failing",
+	},
+}
+
 // buildCLICommands builds the list of available commands for the cli.
 func buildCLICommands() []cli.Command {
 	return []cli.Command{
 		{
-			Name:   "run",
-			Usage:  "runs your test suites",
-			Action: runAction,
+			Name:      "run",
+			Usage:     "runs your test suites",
+			ArgsUsage: "[files or directories...]",
+			Action:    runAction,
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "no-color",
+					Usage: "disable colored output and live progress",
+				},
+				cli.StringSliceFlag{
+					Name:  "tag",
+					Usage: "only run tests with the given tag, may be repeated",
+				},
+				cli.StringSliceFlag{
+					Name:  "exclude-tag",
+					Usage: "skip tests with the given tag, may be repeated",
+				},
+				cli.StringFlag{
+					Name:  "grep",
+					Usage: "only run tests whose description or endpoint match the given regular expression",
+				},
+				cli.IntFlag{
+					Name:  "rerun-failures",
+					Usage: "run failed tests again up to `N` times, tests passing when run again are reported as flaky",
+				},
+			}, suiteFlags...),
 		},
 		{
-			Name:   "verify",
-			Usage:  "verify an api-check file",
-			Action: verifyAction,
+			Name:      "verify",
+			Usage:     "verify api-check files",
+			ArgsUsage: "[filenames...]",
+			Action:    verifyAction,
+			Flags:     suiteFlags,
 		},
 		{
 			Name:    "generate",
 			Aliases: []string{"gen"},
 			Usage:   "generate a skeleton api-check file",
 			Action:  generateAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Value: string(builder.FormatJSON),
+					Usage: "format of the generated file, json or yaml",
+				},
+			},
 		},
 	}
 }
@@ -3,6 +3,10 @@ package config
 import (
 	"encoding/json"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/JonathonGore/api-check/builder"
 )
 
 // Config is used to specifiy global config used by the api-check CLI and Go
@@ -13,15 +17,108 @@ type Config struct {
 	// specifiy this for each test definition.
 	Hostname string `json:"hostname"`
 
-	// Name of a bash script to execute before running the test suite.
+	// Name of a bash script to execute before running the test suite. Relative
+	// paths are relative to the directory containing the config file.
 	SetupScript string `json:"setup-script"`
 
 	// Name of a bash script to execute before finishing the test suite.
+	// Relative paths are relative to the directory containing the config file.
 	CleanupScript string `json:"cleanup-script"`
 
 	// MuteScriptOutput determines if the output from setup and cleanup script
 	// should be surpressed.
 	MuteScriptOutput bool `json:"mute-script-output"`
+
+	// Include, when non-empty, limits test discovery to test definitions
+	// matching at least one of these glob patterns. Patterns use .gitignore
+	// syntax relative to the directory being searched.
+	Include []string `json:"include"`
+
+	// Exclude skips test definitions and directories matching any of these
+	// glob patterns during test discovery.
+	Exclude []string `json:"exclude"`
+
+	// Parallel determines if tests run through `go test` are run in parallel
+	// with each other.
+	Parallel bool `json:"parallel"`
+
+	// FileSessions gives every test definition file its own cookie session, so
+	// cookies set by one test are sent by the following tests in the file.
+	// Tests naming a session of their own use it instead.
+	FileSessions bool `json:"file-sessions"`
+
+	// Auth is the default authentication of every request which does not give
+	// its own.
+	Auth *builder.Auth `json:"auth"`
+
+	// Signing, when non-nil, signs every request using an HMAC.
+	Signing *Signing `json:"signing"`
+
+	// HTTP configures the client used to send every request.
+	HTTP HTTP `json:"http"`
+}
+
+// HTTP configures the client used to send requests. A single client is shared
+// by every test in a run so connections are reused.
+type HTTP struct {
+	// CABundle is a PEM file of certificate authorities trusted in addition
+	// to those of the system. Relative paths are relative to the directory
+	// containing the config file.
+	CABundle string `json:"ca-bundle"`
+
+	// InsecureSkipVerify disables verification of the server's certificate.
+	InsecureSkipVerify bool `json:"insecure-skip-verify"`
+
+	// ClientCert and ClientKey are PEM files used to authenticate using
+	// mutual TLS. Relative paths are relative to the directory containing the
+	// config file.
+	ClientCert string `json:"client-cert"`
+	ClientKey  string `json:"client-key"`
+
+	// Proxy is the URL of the proxy requests are sent through. By default the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+	Proxy string `json:"proxy"`
+
+	// Redirects is either "follow", the default, or "none" to receive the
+	// redirect response itself.
+	Redirects string `json:"redirects"`
+
+	// MaxRedirects is how many redirects are followed before failing, by
+	// default 10.
+	MaxRedirects int `json:"max-redirects"`
+
+	// DisableKeepAlives sends each request on a new connection.
+	DisableKeepAlives bool `json:"disable-keep-alives"`
+}
+
+// Signing describes how requests are signed using an HMAC of a canonical
+// string built from each request.
+type Signing struct {
+	// Header is the name of the header the signature is sent in.
+	Header string `json:"header"`
+
+	// Prefix is written before the signature in the header, for example
+	// "HMAC-SHA256 ".
+	Prefix string `json:"prefix"`
+
+	// Algorithm is the hash used, one of "sha1", "sha256" or "sha512".
+	Algorithm string `json:"algorithm"`
+
+	// Secret is the key of the HMAC, it may refer to an environment variable
+	// using `${ENV:NAME}`.
+	Secret string `json:"secret"`
+
+	// Template is the layout of the canonical string that is signed. It may
+	// use the placeholders {method}, {host}, {path}, {query}, {timestamp},
+	// {content-type}, {body} and {body-sha256}.
+	Template string `json:"template"`
+
+	// TimestampHeader, when non-empty, is the header the unix timestamp used
+	// in the signature is sent in.
+	TimestampHeader string `json:"timestamp-header"`
+
+	// Encoding of the signature, either "hex" or "base64".
+	Encoding string `json:"encoding"`
 }
 
 const (
@@ -30,6 +127,19 @@ const (
 
 	// The default option for muting script output.
 	DefaultMuteScriptOutput = false
+
+	// The defaults used for signing requests.
+	DefaultSigningHeader    = "X-Signature"
+	DefaultSigningAlgorithm = "sha256"
+	DefaultSigningTemplate  = "{method}\n{path}\n{timestamp}\n{body}"
+	DefaultSigningEncoding  = "hex"
+
+	// The redirect policies of the HTTP client.
+	RedirectsFollow = "follow"
+	RedirectsNone   = "none"
+
+	// The default number of redirects followed by the HTTP client.
+	DefaultMaxRedirects = 10
 )
 
 // DefaultConfig we will use for the app.
@@ -39,14 +149,31 @@ var DefaultConfig = Config{
 	MuteScriptOutput: DefaultMuteScriptOutput,
 }
 
+// DefaultPath produces the path of the default config file for the test
+// definitions stored in dir.
+func DefaultPath(dir string) string {
+	return filepath.Join(dir, DefaultConfigFile)
+}
+
 // New creats a new config object from the given filename.
+// When the file does not exist DefaultConfig is used, see Read to require it.
 func New(filename string) (Config, error) {
+	conf, err := Read(filename)
+	if os.IsNotExist(err) {
+		// Dont return an error because its not needed to have a config file
+		return DefaultConfig, nil
+	}
+
+	return conf, err
+}
+
+// Read creates a new config object from the given filename, which must exist.
+func Read(filename string) (Config, error) {
 	var conf Config
 
 	contents, err := ioutil.ReadFile(filename)
 	if err != nil {
-		// Dont return an error because its not needed to have a config file
-		return DefaultConfig, nil
+		return conf, err
 	}
 
 	if err := json.Unmarshal(contents, &conf); err != nil {
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"reflect"
 	"testing"
 )
 
@@ -13,7 +15,19 @@ func TestNonExistentConfig(t *testing.T) {
 	}
 
 	expected := Config{}
-	if c != expected {
+	if !reflect.DeepEqual(c, expected) {
 		t.Fatalf("expected to receive empty config but got: %v", c)
 	}
 }
+
+func TestReadNonExistentConfig(t *testing.T) {
+	if _, err := Read("non-existent-file.json"); !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error but received: %v", err)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	if path := DefaultPath("api/users"); path != "api/users/.ac.json" {
+		t.Errorf("expected api/users/.ac.json but received: %v", path)
+	}
+}
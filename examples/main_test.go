@@ -7,18 +7,11 @@ import (
 	"github.com/JonathonGore/api-check/suite"
 )
 
-func setup() {
-	http.HandleFunc("/foo", fooHandler)
-
-	go http.ListenAndServe(":8080", nil)
-}
-
-func cleanup() {
-	// Clean up test server here
-}
-
 func TestAPICheck(t *testing.T) {
-	setup()
-	suite.Run(t)
-	cleanup()
+	// Requests are served in-process by the handler, no server needs to be
+	// started beforehand.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/foo", fooHandler)
+
+	suite.RunHandler(t, mux)
 }
@@ -0,0 +1,66 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// Filter describes which tests should be selected from the loaded test
+// definitions. The zero value selects every test.
+type Filter struct {
+	// Tags, when non-empty, selects only tests with at least one of the tags.
+	Tags []string
+
+	// ExcludeTags removes any test with at least one of the tags.
+	ExcludeTags []string
+
+	// Grep is a regular expression, when non-empty only tests whose
+	// description or endpoint match it are selected.
+	Grep string
+}
+
+// hasAnyTag determines if the test is tagged with at least one of tags.
+func hasAnyTag(test builder.APITest, tags []string) bool {
+	for _, tag := range tags {
+		for _, t := range test.Tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Apply consumes a list of tests and produces the tests selected by the
+// filter, preserving their order.
+func (f Filter) Apply(tests []builder.APITest) ([]builder.APITest, error) {
+	var grep *regexp.Regexp
+	if f.Grep != "" {
+		var err error
+		if grep, err = regexp.Compile(f.Grep); err != nil {
+			return nil, fmt.Errorf("invalid grep pattern: %v", err)
+		}
+	}
+
+	selected := []builder.APITest{}
+	for _, test := range tests {
+		if len(f.Tags) > 0 && !hasAnyTag(test, f.Tags) {
+			continue
+		}
+
+		if hasAnyTag(test, f.ExcludeTags) {
+			continue
+		}
+
+		if grep != nil && !grep.MatchString(test.Description) && !grep.MatchString(test.Endpoint) {
+			continue
+		}
+
+		selected = append(selected, test)
+	}
+
+	return selected, nil
+}
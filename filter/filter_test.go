@@ -0,0 +1,53 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+var tests = []builder.APITest{
+	{Description: "list users", Endpoint: "/users", Tags: []string{"smoke"}},
+	{Description: "create user", Endpoint: "/users", Tags: []string{"smoke", "slow"}},
+	{Description: "list apps", Endpoint: "/apps"},
+}
+
+var applyTests = []struct {
+	filter   Filter
+	expected []string
+}{
+	{Filter{}, []string{"list users", "create user", "list apps"}},
+	{Filter{Tags: []string{"smoke"}}, []string{"list users", "create user"}},
+	{Filter{ExcludeTags: []string{"slow"}}, []string{"list users", "list apps"}},
+	{Filter{Tags: []string{"smoke"}, ExcludeTags: []string{"slow"}}, []string{"list users"}},
+	{Filter{Grep: "apps"}, []string{"list apps"}},
+	{Filter{Grep: "^/users$"}, []string{"list users", "create user"}},
+	{Filter{Tags: []string{"missing"}}, []string{}},
+}
+
+func TestApply(t *testing.T) {
+	for i, test := range applyTests {
+		selected, err := test.filter.Apply(tests)
+		if err != nil {
+			t.Errorf("test #%v: unexpected error: %v", i, err)
+			continue
+		}
+
+		if len(selected) != len(test.expected) {
+			t.Errorf("test #%v: expected %v tests but received %v", i, len(test.expected), len(selected))
+			continue
+		}
+
+		for j, description := range test.expected {
+			if selected[j].Description != description {
+				t.Errorf("test #%v: expected %v but received %v", i, description, selected[j].Description)
+			}
+		}
+	}
+}
+
+func TestApplyInvalidGrep(t *testing.T) {
+	if _, err := (Filter{Grep: "("}).Apply(tests); err == nil {
+		t.Errorf("expected invalid grep pattern to produce an error")
+	}
+}
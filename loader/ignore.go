@@ -0,0 +1,140 @@
+package loader
+
+import (
+	"path"
+	"strings"
+)
+
+const (
+	// ignoreFile is the name of the file listing paths to skip during test
+	// discovery, using the same syntax as a .gitignore file.
+	ignoreFile = ".acignore"
+)
+
+// pattern is a single line of an ignore file or an include/exclude glob.
+type pattern struct {
+	glob string
+
+	// negate re-includes paths matched by earlier patterns (`!pattern`).
+	negate bool
+
+	// dirOnly only matches directories (`pattern/`).
+	dirOnly bool
+
+	// anchored patterns are matched against the full relative path, otherwise
+	// only against the final element of the path.
+	anchored bool
+}
+
+// parsePattern parses a single gitignore style pattern. The boolean result is
+// false for blank lines and comments.
+func parsePattern(line string) (pattern, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	var p pattern
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// A slash anywhere but the end anchors the pattern to the root.
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	p.glob = line
+	return p, line != ""
+}
+
+// parsePatterns parses every pattern in the given list of lines.
+func parsePatterns(lines []string) []pattern {
+	var patterns []pattern
+	for _, line := range lines {
+		if p, ok := parsePattern(line); ok {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns
+}
+
+// matches determines if the pattern matches the given slash separated path,
+// relative to the directory being searched.
+func (p pattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if !p.anchored {
+		return matchGlob(p.glob, path.Base(rel))
+	}
+
+	return matchGlob(p.glob, rel)
+}
+
+// matchGlob matches a slash separated path against a glob where each element
+// is matched using path.Match, and `**` matches zero or more elements.
+func matchGlob(glob, name string) bool {
+	return matchElements(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+// matchElements matches the elements of a path against the elements of a glob.
+func matchElements(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			// Try consuming every possible number of path elements.
+			for i := 0; i <= len(name); i++ {
+				if matchElements(glob[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, err := path.Match(glob[0], name[0]); err != nil || !ok {
+			return false
+		}
+
+		glob, name = glob[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// ignored determines if the path is ignored by the list of patterns. Like a
+// .gitignore file the last matching pattern wins.
+func ignored(patterns []pattern, rel string, isDir bool) bool {
+	result := false
+	for _, p := range patterns {
+		if p.matches(rel, isDir) {
+			result = !p.negate
+		}
+	}
+
+	return result
+}
+
+// matchesAny determines if any of the patterns match the path.
+func matchesAny(patterns []pattern, rel string, isDir bool) bool {
+	for _, p := range patterns {
+		if !p.negate && p.matches(rel, isDir) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package loader
+
+import (
+	"testing"
+)
+
+var matchGlobTests = []struct {
+	glob   string
+	name   string
+	result bool
+}{
+	{"*.ac.json", "users.ac.json", true},
+	{"*.ac.json", "api/users.ac.json", false},
+	{"api/*.ac.json", "api/users.ac.json", true},
+	{"**/users.ac.json", "users.ac.json", true},
+	{"**/users.ac.json", "api/v1/users.ac.json", true},
+	{"api/**", "api/v1/users.ac.json", true},
+	{"api/**/users.ac.json", "api/users.ac.json", true},
+	{"api/**/users.ac.json", "apps/users.ac.json", false},
+	{"[", "[", false},
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, test := range matchGlobTests {
+		if result := matchGlob(test.glob, test.name); result != test.result {
+			t.Errorf("glob %v expected %v for %v but received %v", test.glob, test.result, test.name, result)
+		}
+	}
+}
+
+var ignoredTests = []struct {
+	lines  []string
+	rel    string
+	isDir  bool
+	result bool
+}{
+	{[]string{"# comment", ""}, "users.ac.json", false, false},
+	{[]string{"legacy"}, "api/legacy", true, true},
+	{[]string{"legacy/"}, "api/legacy", false, false},
+	{[]string{"/legacy"}, "api/legacy", true, false},
+	{[]string{"/legacy"}, "legacy", true, true},
+	{[]string{"*.ac.json", "!users.ac.json"}, "api/users.ac.json", false, false},
+	{[]string{"*.ac.json", "!users.ac.json"}, "api/apps.ac.json", false, true},
+}
+
+func TestIgnored(t *testing.T) {
+	for _, test := range ignoredTests {
+		if result := ignored(parsePatterns(test.lines), test.rel, test.isDir); result != test.result {
+			t.Errorf("patterns %v expected %v for %v but received %v", test.lines, test.result, test.rel, result)
+		}
+	}
+}
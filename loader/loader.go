@@ -1,13 +1,15 @@
 package loader
 
 import (
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
-)
+	"sort"
+	"strings"
 
-const (
-	// the extension used for test definition files.
-	extension = ".ac.json"
+	"github.com/JonathonGore/api-check/builder"
+	"github.com/JonathonGore/api-check/config"
 )
 
 // Consumes a filepath and determines if it has a file extension containing
@@ -20,29 +22,242 @@ func hasDoubleDotExt(path, ext string) bool {
 	// Gets the extension of the string without the .json extension
 	inner := filepath.Ext(path[:(len(path) - len(outer))])
 
-	return (inner + outer) == extension
+	return (inner + outer) == ext
 }
 
-// FindRunDefs finds all run definition files in the working directory
-// or in any directory below it.
-func FindTestDefinitions(dir string) ([]string, error) {
+// isTestDefinition determines if the file has the extension of one of the
+// supported test definition formats.
+func isTestDefinition(file string) bool {
+	for _, ext := range builder.Extensions {
+		// When the name is the extension we are looking at the global
+		// configuration file '.ac.json'.
+		if path.Base(file) != ext && hasDoubleDotExt(file, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skippedDirs are the directories never searched for test definitions.
+var skippedDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+}
+
+// Loader finds test definition files.
+type Loader struct {
+	include []pattern
+	exclude []pattern
+}
+
+// New consumes an api-check config object and builds a new Loader using its
+// include and exclude patterns.
+func New(conf config.Config) Loader {
+	return Loader{
+		include: parsePatterns(conf.Include),
+		exclude: parsePatterns(conf.Exclude),
+	}
+}
+
+// readIgnoreFile reads the patterns in the ignore file of the given directory,
+// a missing ignore file produces no patterns.
+func readIgnoreFile(dir string) ([]pattern, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(dir, ignoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return parsePatterns(strings.Split(string(contents), "\n")), nil
+}
+
+// skipDir determines if the directory with the given relative path should not
+// be searched.
+func (l Loader) skipDir(rel string, ignore []pattern) bool {
+	name := path.Base(rel)
+	if strings.HasPrefix(name, ".") || skippedDirs[name] {
+		return true
+	}
+
+	return ignored(ignore, rel, true) || matchesAny(l.exclude, rel, true)
+}
+
+// selectFile determines if the file with the given relative path is a test
+// definition that should be loaded.
+func (l Loader) selectFile(rel string, ignore []pattern) bool {
+	if !isTestDefinition(rel) {
+		return false
+	}
+
+	if ignored(ignore, rel, false) || matchesAny(l.exclude, rel, false) {
+		return false
+	}
+
+	return len(l.include) == 0 || matchesAny(l.include, rel, false)
+}
+
+// Errors is the list of every problem encountered during test discovery.
+type Errors []error
+
+// Error lists the message of each problem on its own line.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// walker holds the state of a single search for test definitions.
+type walker struct {
+	loader Loader
+	root   string
+	ignore []pattern
+
+	// visited contains the real path of every directory searched so far, so
+	// directories reached through symlinks are never searched twice.
+	visited map[string]bool
+
+	files []string
+	errs  Errors
+}
+
+// walk searches the given directory and every directory below it, following
+// symlinks. Problems are recorded and the search continues.
+func (w *walker) walk(dir string) {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		w.errs = append(w.errs, err)
+		return
+	}
+
+	// Symlinks can form loops, so only search each directory once.
+	if w.visited[real] {
+		return
+	}
+	w.visited[real] = true
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		w.errs = append(w.errs, err)
+		return
+	}
+
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+
+		rel, err := filepath.Rel(w.root, file)
+		if err != nil {
+			w.errs = append(w.errs, err)
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		if entry.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Stat(file)
+			if err != nil {
+				// A broken link is only a problem when it names a test
+				// definition which would have been loaded.
+				if w.loader.selectFile(rel, w.ignore) {
+					w.errs = append(w.errs, err)
+				}
+				continue
+			}
+			entry = target
+		}
+
+		if entry.IsDir() {
+			if !w.loader.skipDir(rel, w.ignore) {
+				w.walk(file)
+			}
+			continue
+		}
+
+		if w.loader.selectFile(rel, w.ignore) {
+			w.files = append(w.files, file)
+		}
+	}
+}
+
+// FindTestDefinitions finds all test definition files in the given directory
+// or in any directory below it, sorted by name. Hidden, vendor and
+// node_modules directories are skipped, as are paths matched by the
+// directory's .acignore file or the loader's exclude patterns. Symlinked
+// directories are followed.
+//
+// Every file that could be found is returned even when an error occurs, in
+// which case the error is an Errors listing each problem encountered.
+func (l Loader) FindTestDefinitions(dir string) ([]string, error) {
+	ignore, err := readIgnoreFile(dir)
+	if err != nil {
+		return nil, Errors{err}
+	}
+
+	w := walker{
+		loader:  l,
+		root:    dir,
+		ignore:  ignore,
+		visited: make(map[string]bool),
+	}
+	w.walk(dir)
+
+	sort.Strings(w.files)
+
+	if len(w.errs) > 0 {
+		return w.files, w.errs
+	}
+
+	return w.files, nil
+}
+
+// FindAll consumes a list of files and directories and produces every test
+// definition file they refer to. Files are used as is while directories are
+// searched using FindTestDefinitions.
+//
+// Every file that could be found is returned even when an error occurs, in
+// which case the error is an Errors listing each problem encountered.
+func (l Loader) FindAll(paths []string) ([]string, error) {
 	var files []string
+	var errs Errors
+
+	for _, name := range paths {
+		info, err := os.Stat(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
 
-	filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
-		if err != nil || filepath.Base(path) == extension {
-			// When filepath.Base(path) == extension we are looking at the
-			// global configuration file '.ac.json'.
-			// Note: if err != nil we currently will not return an error as this
-			// will cause all other directories/files to be skipped.
-			return nil
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
 		}
 
-		// We are looking to detect the .ac.json file extension
-		if !f.IsDir() && hasDoubleDotExt(path, extension) {
-			files = append(files, path)
+		found, err := l.FindTestDefinitions(name)
+		if e, ok := err.(Errors); ok {
+			errs = append(errs, e...)
 		}
-		return nil
-	})
+
+		files = append(files, found...)
+	}
+
+	if len(errs) > 0 {
+		return files, errs
+	}
 
 	return files, nil
 }
+
+// FindTestDefinitions finds all test definition files in the given directory
+// or in any directory below it using a default Loader.
+func FindTestDefinitions(dir string) ([]string, error) {
+	return Loader{}.FindTestDefinitions(dir)
+}
+
+// FindAll finds every test definition file referred to by the given files and
+// directories using a default Loader.
+func FindAll(paths []string) ([]string, error) {
+	return Loader{}.FindAll(paths)
+}
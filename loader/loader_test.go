@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/JonathonGore/api-check/config"
 )
 
 var doubleDotExtTests = []struct {
@@ -17,6 +21,8 @@ var doubleDotExtTests = []struct {
 	{"users.ac.json.json", ".ac.json", false},
 	{"users.ac.ac.json", ".ac.json", true},
 	{"", ".ac.json", false},
+	{"users.ac.yaml", ".ac.yaml", true},
+	{"users.ac.yaml", ".ac.json", false},
 }
 
 func TestHasDoubleDotExt(t *testing.T) {
@@ -81,3 +87,186 @@ func TestFindTestDefinitions(t *testing.T) {
 		t.Fatalf("did not receive expected files from FindTestDefinitions")
 	}
 }
+
+func TestFindAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testing")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory for testing")
+	}
+	defer os.RemoveAll(dir)
+
+	subdir, err := ioutil.TempDir(dir, "testing")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory for testing")
+	}
+
+	tmpfile, err := ioutil.TempFile(dir, "*.ac.json")
+	if err != nil {
+		t.Fatalf("unable to create temporary file for testing")
+	}
+
+	subtmpfile, err := ioutil.TempFile(subdir, "*.ac.json")
+	if err != nil {
+		t.Fatalf("unable to create temporary file for testing")
+	}
+
+	// Only the explicitly named file and the files below subdir should be found.
+	files, err := FindAll([]string{tmpfile.Name(), subdir})
+	if err != nil {
+		t.Fatalf("received error %v when trying to find test definitions", err)
+	}
+
+	if len(files) != 2 || !contains(files, tmpfile.Name()) || !contains(files, subtmpfile.Name()) {
+		t.Fatalf("did not receive expected files from FindAll: %v", files)
+	}
+
+	if _, err := FindAll([]string{dir + "/does-not-exist"}); err == nil {
+		t.Fatalf("expected an error when given a path that does not exist")
+	}
+}
+
+// createFiles creates each of the given files, and any missing parent
+// directories, below dir.
+func createFiles(t *testing.T, dir string, files ...string) {
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unable to create directory for testing: %v", err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte("[]"), 0644); err != nil {
+			t.Fatalf("unable to create file for testing: %v", err)
+		}
+	}
+}
+
+func TestLoaderFindTestDefinitions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testing")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory for testing")
+	}
+	defer os.RemoveAll(dir)
+
+	createFiles(t, dir,
+		"b.ac.json",
+		"a.ac.json",
+		".ac.json",
+		"api/users.ac.json",
+		"api/legacy/old.ac.json",
+		"api/draft.ac.json",
+		".git/hooks.ac.json",
+		"vendor/lib/lib.ac.json",
+		"node_modules/pkg/pkg.ac.json",
+		"wip/wip.ac.json",
+		"c.ac.yaml",
+		"d.ac.yml",
+		".ac.yaml",
+	)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".acignore"), []byte("# work in progress\n/wip/\n"), 0644); err != nil {
+		t.Fatalf("unable to create ignore file for testing: %v", err)
+	}
+
+	l := New(config.Config{Exclude: []string{"legacy/", "draft.*"}})
+	files, err := l.FindTestDefinitions(dir)
+	if err != nil {
+		t.Fatalf("received error %v when trying to find test definitions", err)
+	}
+
+	expected := []string{
+		filepath.Join(dir, "a.ac.json"),
+		filepath.Join(dir, "api/users.ac.json"),
+		filepath.Join(dir, "b.ac.json"),
+		filepath.Join(dir, "c.ac.yaml"),
+		filepath.Join(dir, "d.ac.yml"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v but received %v", expected, files)
+	}
+
+	l = New(config.Config{Include: []string{"api/**"}})
+	files, err = l.FindTestDefinitions(dir)
+	if err != nil {
+		t.Fatalf("received error %v when trying to find test definitions", err)
+	}
+
+	expected = []string{
+		filepath.Join(dir, "api/draft.ac.json"),
+		filepath.Join(dir, "api/legacy/old.ac.json"),
+		filepath.Join(dir, "api/users.ac.json"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v but received %v", expected, files)
+	}
+}
+
+func TestFindTestDefinitionsErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testing")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory for testing")
+	}
+	defer os.RemoveAll(dir)
+
+	createFiles(t, dir, "users.ac.json", "api/apps.ac.json")
+
+	// A symlink loop must not be searched forever and a broken symlink should
+	// be reported without hiding the files that could be found.
+	if err := os.Symlink(dir, filepath.Join(dir, "api", "loop")); err != nil {
+		t.Fatalf("unable to create symlink for testing: %v", err)
+	}
+
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "broken.ac.json")); err != nil {
+		t.Fatalf("unable to create symlink for testing: %v", err)
+	}
+
+	// Broken symlinks which are not test definitions, or are ignored, are not
+	// problems.
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "api", "latest")); err != nil {
+		t.Fatalf("unable to create symlink for testing: %v", err)
+	}
+
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "ignored.ac.json")); err != nil {
+		t.Fatalf("unable to create symlink for testing: %v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".acignore"), []byte("ignored.ac.json\n"), 0644); err != nil {
+		t.Fatalf("unable to write ignore file for testing: %v", err)
+	}
+
+	files, err := FindTestDefinitions(dir)
+	if errs, ok := err.(Errors); !ok || len(errs) != 1 {
+		t.Errorf("expected a single discovery error but received: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(dir, "api/apps.ac.json"),
+		filepath.Join(dir, "users.ac.json"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v but received %v", expected, files)
+	}
+}
+
+func TestFindTestDefinitionsFollowsSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testing")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory for testing")
+	}
+	defer os.RemoveAll(dir)
+
+	createFiles(t, dir, "shared/users.ac.json")
+
+	if err := os.Symlink(filepath.Join(dir, "shared"), filepath.Join(dir, "linked")); err != nil {
+		t.Fatalf("unable to create symlink for testing: %v", err)
+	}
+
+	// The shared directory is reachable twice but must only be searched once.
+	files, err := FindTestDefinitions(dir)
+	if err != nil {
+		t.Fatalf("received error %v when trying to find test definitions", err)
+	}
+
+	if len(files) != 1 {
+		t.Errorf("expected to find a single test definition but found: %v", files)
+	}
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is a problem found in a test definition file.
+type Error struct {
+	File string
+
+	// Line and Column locate the problem within the file, they are 0 when
+	// the problem is not specific to a location.
+	Line   int
+	Column int
+
+	// Test is the number of the test containing the problem starting at 1,
+	// it is 0 when the problem is not specific to a test.
+	Test int
+
+	Err error
+}
+
+// Error formats the problem as `file:line:column: error in test #n: message`.
+func (e Error) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%v:%v:%v", e.File, e.Line, e.Column)
+	}
+
+	if e.Test > 0 {
+		return fmt.Sprintf("%v: error in test #%v: %v", location, e.Test, e.Err)
+	}
+
+	return fmt.Sprintf("%v: %v", location, e.Err)
+}
+
+// Errors is every problem found while parsing test definition files.
+type Errors []Error
+
+// Error lists each problem on its own line.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
@@ -6,10 +6,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/JonathonGore/api-check/builder"
 	"github.com/JonathonGore/api-check/config"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -21,6 +25,10 @@ const (
 type Parser struct {
 	conf    config.Config
 	methods map[string]bool
+
+	// strict determines if unknown fields are errors rather than warnings.
+	strict   bool
+	warnings []error
 }
 
 // New consumes a api-check config object and builds a new
@@ -44,44 +52,302 @@ func New(conf config.Config) Parser {
 	}
 }
 
+// Strict determines if unknown fields in test definitions, which are most
+// likely typos, cause parsing to fail. Otherwise they are recorded as warnings.
+func (p *Parser) Strict(strict bool) {
+	p.strict = strict
+}
+
+// Warnings produces the problems found while parsing that did not cause
+// parsing to fail.
+func (p *Parser) Warnings() []error {
+	return p.warnings
+}
+
+// fieldError is a problem with the value of a single field of a test.
+type fieldError struct {
+	// field is the path of the field within the test, such as "response.code".
+	field string
+	err   error
+}
+
+// at locates a problem in a test within the file. The test index starts at 0.
+func at(file string, pos positions, test int, field string, err error) Error {
+	e := Error{File: file, Test: test + 1, Err: err}
+
+	path := fmt.Sprintf("[%v]", test)
+	if field != "" {
+		path = joinPath(path, field)
+	}
+
+	if p, ok := pos.lookup(path); ok {
+		e.Line, e.Column = p.line, p.column
+	}
+
+	return e
+}
+
 // ParseFile consumes a single filename and parses it into a list of api tests.
+// Every problem found in the file is returned as Errors.
 func (p *Parser) ParseFile(file string) ([]builder.APITest, error) {
 	tests := []builder.APITest{}
 
 	contents, err := ioutil.ReadFile(file)
 	if err != nil {
-		return tests, err
+		return tests, Errors{{File: file, Err: err}}
+	}
+
+	// YAML definitions are converted to JSON so both formats are decoded into
+	// tests in exactly the same way, positions are found in the original file.
+	var pos positions
+	if isYAML(file) {
+		pos = yamlPositions(contents)
+		if contents, err = yamlToJSON(contents, reflect.TypeOf([]builder.APITest{})); err != nil {
+			return tests, Errors{{File: file, Err: err}}
+		}
+	} else {
+		pos = jsonPositions(contents)
+	}
+
+	// Each test is decoded separately so every malformed test is reported.
+	var raw []json.RawMessage
+	if err := json.Unmarshal(contents, &raw); err != nil {
+		e := Error{File: file, Err: err}
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			p := offsetPosition(contents, syntaxErr.Offset)
+			e.Line, e.Column = p.line, p.column
+		} else if _, ok := err.(*json.UnmarshalTypeError); ok {
+			e.Err = fmt.Errorf("test definition files must contain an array of tests")
+		}
+
+		return tests, Errors{e}
 	}
 
-	// TODO: It would be cool if we could detect extra fields and warn the user about them
-	err = json.Unmarshal(contents, &tests)
+	var errs Errors
+
+	unknown, err := checkUnknownFields(contents, reflect.TypeOf(builder.APITest{}))
 	if err != nil {
-		return tests, err
+		return tests, Errors{{File: file, Err: err}}
+	}
+
+	for _, field := range unknown {
+		e := at(file, pos, field.test, field.path, field)
+		if p.strict {
+			errs = append(errs, e)
+		} else {
+			p.warnings = append(p.warnings, e)
+		}
 	}
 
-	for i, test := range tests {
-		if tests[i], err = p.validate(test); err != nil {
-			return tests, fmt.Errorf("error in test #%v: %v", i+1, err)
+	for i, message := range raw {
+		var test builder.APITest
+		if err := json.Unmarshal(message, &test); err != nil {
+			if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+				err = fmt.Errorf("invalid value for %v: expected %v but found %v", typeErr.Field, typeErr.Type, typeErr.Value)
+				errs = append(errs, at(file, pos, i, typeErr.Field, err))
+			} else {
+				// Errors from types which decode themselves are not located by
+				// encoding/json, so the value which caused it is searched for.
+				field, _ := findUnmarshalError(message, reflect.TypeOf(test))
+				errs = append(errs, at(file, pos, i, field, err))
+			}
+			continue
+		}
+
+		test, problems := p.validate(test)
+		for _, problem := range problems {
+			errs = append(errs, at(file, pos, i, problem.field, problem.err))
 		}
+
+		test.File = file
+		if test.Session == "" && p.conf.FileSessions {
+			test.Session = file
+		}
+
+		tests = append(tests, test)
+	}
+
+	if len(errs) > 0 {
+		return tests, errs
 	}
 
 	return tests, nil
 }
 
+// isYAML determines if the file is a YAML test definition file.
+func isYAML(file string) bool {
+	return strings.HasSuffix(file, builder.YAMLExtension) || strings.HasSuffix(file, builder.YMLExtension)
+}
+
+// mergeContent produces the content of a mapping node with every merge key,
+// such as `<<: *base`, replaced by the keys and values of the mappings it
+// names. Merged mappings come first, in reverse order, so that explicit keys
+// take precedence over merged ones and earlier merged mappings over later ones.
+func mergeContent(node *yaml.Node) ([]*yaml.Node, error) {
+	var merged, explicit []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Tag != "!!merge" {
+			explicit = append(explicit, key, value)
+			continue
+		}
+
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+
+		for j := len(sources) - 1; j >= 0; j-- {
+			source := sources[j]
+			for source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+
+			if source.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("yaml: line %v: merge keys must name a mapping or a sequence of mappings", key.Line)
+			}
+
+			content, err := mergeContent(source)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, content...)
+		}
+	}
+
+	return append(merged, explicit...), nil
+}
+
+// yamlValue converts a YAML node into the value it would be as JSON. The node
+// is decoded into the type t, so scalars such as `page: 1` become strings when
+// a string is expected. A nil t decodes the node without a type.
+func yamlValue(node *yaml.Node, t reflect.Type) (interface{}, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Types which decode themselves accept any value, so are decoded untyped.
+	if t != nil && reflect.PtrTo(t).Implements(unmarshalerType) {
+		t = nil
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0], t)
+	case yaml.AliasNode:
+		return yamlValue(node.Alias, t)
+	case yaml.ScalarNode:
+		if t != nil && t.Kind() == reflect.String && node.Tag != "!!null" {
+			return node.Value, nil
+		}
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+
+		values := make([]interface{}, len(node.Content))
+		for i, child := range node.Content {
+			value, err := yamlValue(child, elem)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+
+		return values, nil
+	case yaml.MappingNode:
+		var fields map[string]reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = jsonFields(t)
+		}
+
+		content, err := mergeContent(node)
+		if err != nil {
+			return nil, err
+		}
+
+		// Content alternates between each key and its value, later keys
+		// replacing earlier ones.
+		object := make(map[string]interface{})
+		for i := 0; i+1 < len(content); i += 2 {
+			key := content[i].Value
+
+			var field reflect.Type
+			if fields != nil {
+				field, _ = lookupField(fields, key)
+			} else if t != nil && t.Kind() == reflect.Map {
+				field = t.Elem()
+			}
+
+			value, err := yamlValue(content[i+1], field)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = value
+		}
+
+		return object, nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// yamlToJSON converts the contents of a YAML document into the equivalent JSON,
+// using the type t the JSON is decoded into to decide the type of each scalar.
+func yamlToJSON(contents []byte, t reflect.Type) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(contents, &node); err != nil {
+		return nil, err
+	}
+
+	value, err := yamlValue(&node, t)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty document contains no tests.
+	if value == nil {
+		return []byte("[]"), nil
+	}
+
+	d, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert YAML to JSON: %v", err)
+	}
+
+	return d, nil
+}
+
 // Parse consumes a list of filenames and attempts to parse them into a list of
-// api tests.
+// api tests. Every problem found in every file is returned as Errors.
 func (p *Parser) Parse(filenames []string) ([]builder.APITest, error) {
 	tests := []builder.APITest{}
+	var errs Errors
 
 	for _, name := range filenames {
 		results, err := p.ParseFile(name)
-		if err != nil {
-			return tests, fmt.Errorf("error parsing file: %v - %v", name, err)
+		if e, ok := err.(Errors); ok {
+			errs = append(errs, e...)
+		} else if err != nil {
+			errs = append(errs, Error{File: name, Err: err})
 		}
 
 		tests = append(tests, results...)
 	}
 
+	if len(errs) > 0 {
+		return tests, errs
+	}
+
 	return tests, nil
 }
 
@@ -160,30 +426,328 @@ func (p *Parser) validateEndpoint(endpoint string) (string, error) {
 	return endpoint, nil
 }
 
+// noBodyStatusCodes are the status codes of responses which never contain a
+// body.
+var noBodyStatusCodes = map[int]bool{
+	http.StatusNoContent:   true,
+	http.StatusNotModified: true,
+}
+
+// validateRequest asserts the request does not give both a raw body and JSON,
+// as only the JSON would be sent.
+func (p *Parser) validateRequest(request builder.APIRequest) []fieldError {
+	if request.Body != "" && request.JSON != nil {
+		return []fieldError{{"request.json", fmt.Errorf("request.json conflicts with request.body, only one may be given")}}
+	}
+
+	return nil
+}
+
+// validateResponse asserts the expected response does not contradict itself.
+// Only one of emptyBody, body, json and ofType may describe the body, and no
+// body may be expected when the method or status code means one is never sent.
+func (p *Parser) validateResponse(method string, response builder.APIResponse) []fieldError {
+	var problems []fieldError
+
+	// Each assertion on the body in the order they appear in a definition.
+	var given []string
+	if response.EmptyBody {
+		given = append(given, "emptyBody")
+	}
+	if response.Body != "" {
+		given = append(given, "body")
+	}
+	if response.JSON != nil {
+		given = append(given, "json")
+	}
+	if response.TypeOf != nil {
+		given = append(given, "ofType")
+	}
+
+	for i := 1; i < len(given); i++ {
+		err := fmt.Errorf("response.%v conflicts with response.%v, only one of emptyBody, body, json and ofType may be given", given[i], given[0])
+		problems = append(problems, fieldError{"response." + given[i], err})
+	}
+
+	if response.ContentLength != nil {
+		if *response.ContentLength < 0 {
+			problems = append(problems, fieldError{"response.contentLength", fmt.Errorf("response.contentLength cannot be negative")})
+		} else if *response.ContentLength > 0 && response.EmptyBody {
+			err := fmt.Errorf("response.contentLength conflicts with response.emptyBody")
+			problems = append(problems, fieldError{"response.contentLength", err})
+		}
+	}
+
+	// Expecting an empty body is always consistent with receiving no body.
+	if len(given) == 0 || given[0] == "emptyBody" {
+		return problems
+	}
+
+	if method == http.MethodHead {
+		err := fmt.Errorf("response.%v is never received for a HEAD request", given[0])
+		problems = append(problems, fieldError{"response." + given[0], err})
+	} else if noBodyStatusCodes[response.StatusCode] {
+		err := fmt.Errorf("response.%v is never received with status code %v", given[0], response.StatusCode)
+		problems = append(problems, fieldError{"response." + given[0], err})
+	}
+
+	return problems
+}
+
+// validateHeaders asserts each expected response header can be matched. The
+// regular expressions given must compile and a header expected to be absent
+// cannot also be matched against a value.
+func (p *Parser) validateHeaders(headers map[string]builder.HeaderMatcher) []fieldError {
+	var problems []fieldError
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		matcher, field := headers[key], joinPath("response.headers", key)
+
+		operators := matcher.Regex != "" || matcher.Contains != ""
+		if matcher.Value == nil && matcher.Values == nil && !matcher.Absent && !operators {
+			err := fmt.Errorf("%v header must use at least one of $absent, $regex and $contains", key)
+			problems = append(problems, fieldError{field, err})
+		} else if matcher.Absent && operators {
+			err := fmt.Errorf("%v header cannot be matched when expected to be $absent", key)
+			problems = append(problems, fieldError{field, err})
+		}
+
+		if matcher.Regex != "" {
+			if _, err := regexp.Compile(matcher.Regex); err != nil {
+				problems = append(problems, fieldError{field, fmt.Errorf("invalid $regex for %v header: %v", key, err)})
+			}
+		}
+	}
+
+	return problems
+}
+
+// sameSiteModes are the values allowed for the SameSite attribute of a cookie.
+var sameSiteModes = map[string]bool{"strict": true, "lax": true, "none": true}
+
+// validateCookies asserts each expected response cookie can be matched. The
+// patterns given must compile, SameSite must be a known mode and a cookie
+// expected to be absent cannot also have its attributes checked.
+func (p *Parser) validateCookies(cookies map[string]builder.CookieAssertion) []fieldError {
+	var problems []fieldError
+
+	names := make([]string, 0, len(cookies))
+	for name := range cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cookie, field := cookies[name], joinPath("response.cookies", name)
+
+		if cookie.Absent && cookie != (builder.CookieAssertion{Absent: true}) {
+			err := fmt.Errorf("%v cookie cannot have its attributes checked when expected to be absent", name)
+			problems = append(problems, fieldError{field, err})
+		}
+
+		if cookie.Pattern != "" {
+			if _, err := regexp.Compile(cookie.Pattern); err != nil {
+				problems = append(problems, fieldError{joinPath(field, "pattern"), fmt.Errorf("invalid pattern for %v cookie: %v", name, err)})
+			}
+		}
+
+		if cookie.SameSite != "" && !sameSiteModes[strings.ToLower(cookie.SameSite)] {
+			err := fmt.Errorf("sameSite of %v cookie must be one of Strict, Lax or None but found %v", name, cookie.SameSite)
+			problems = append(problems, fieldError{joinPath(field, "sameSite"), err})
+		}
+
+		if cookie.MaxAge != nil && *cookie.MaxAge < 0 {
+			err := fmt.Errorf("maxAge of %v cookie cannot be negative", name)
+			problems = append(problems, fieldError{joinPath(field, "maxAge"), err})
+		}
+	}
+
+	return problems
+}
+
+// validateAuth asserts at most one way of authenticating is given, and that it
+// is given everything it needs.
+func (p *Parser) validateAuth(auth *builder.Auth) []fieldError {
+	if auth == nil {
+		return nil
+	}
+
+	var problems []fieldError
+
+	var given []string
+	if auth.Basic != nil {
+		given = append(given, "basic")
+	}
+	if auth.Bearer != "" {
+		given = append(given, "bearer")
+	}
+	if auth.APIKey != nil {
+		given = append(given, "apiKey")
+	}
+	if auth.OAuth2 != nil {
+		given = append(given, "oauth2")
+	}
+
+	for i := 1; i < len(given); i++ {
+		err := fmt.Errorf("request.auth.%v conflicts with request.auth.%v, only one of basic, bearer, apiKey and oauth2 may be given", given[i], given[0])
+		problems = append(problems, fieldError{"request.auth." + given[i], err})
+	}
+
+	if auth.Basic != nil && auth.Basic.User == "" {
+		problems = append(problems, fieldError{"request.auth.basic", fmt.Errorf("request.auth.basic requires a user")})
+	}
+
+	if key := auth.APIKey; key != nil {
+		if key.In != builder.APIKeyInHeader && key.In != builder.APIKeyInQuery {
+			err := fmt.Errorf("request.auth.apiKey.in must be one of header or query but found %q", key.In)
+			problems = append(problems, fieldError{"request.auth.apiKey.in", err})
+		}
+
+		if key.Name == "" {
+			problems = append(problems, fieldError{"request.auth.apiKey", fmt.Errorf("request.auth.apiKey requires a name")})
+		}
+	}
+
+	if oauth2 := auth.OAuth2; oauth2 != nil {
+		if oauth2.TokenURL == "" {
+			problems = append(problems, fieldError{"request.auth.oauth2", fmt.Errorf("request.auth.oauth2 requires a tokenUrl")})
+		} else if _, err := url.ParseRequestURI(oauth2.TokenURL); err != nil && !strings.Contains(oauth2.TokenURL, "${ENV:") {
+			problems = append(problems, fieldError{"request.auth.oauth2.tokenUrl", fmt.Errorf("malformed tokenUrl provided")})
+		}
+
+		if oauth2.ClientID == "" {
+			problems = append(problems, fieldError{"request.auth.oauth2", fmt.Errorf("request.auth.oauth2 requires a clientId")})
+		}
+	}
+
+	return problems
+}
+
+// validateRedirects asserts redirects are only expected when they are followed
+// and that each one is a redirect status code.
+func (p *Parser) validateRedirects(test builder.APITest) []fieldError {
+	if test.Response.Redirects == nil {
+		return nil
+	}
+
+	var problems []fieldError
+
+	follow := p.conf.HTTP.Redirects != config.RedirectsNone
+	if test.FollowRedirects != nil {
+		follow = *test.FollowRedirects
+	}
+
+	if !follow {
+		err := fmt.Errorf("response.redirects cannot be asserted when redirects are not followed")
+		problems = append(problems, fieldError{"response.redirects", err})
+	}
+
+	for i, hop := range test.Response.Redirects {
+		if hop.StatusCode < 300 || hop.StatusCode > 399 {
+			err := fmt.Errorf("redirect #%v must have a 3xx status code but found %v", i+1, hop.StatusCode)
+			problems = append(problems, fieldError{fmt.Sprintf("response.redirects[%v].code", i), err})
+		}
+	}
+
+	return problems
+}
+
+// validateRetry asserts the retry policy found at field is usable. A retry
+// which is not eventually must give something to retry on.
+func (p *Parser) validateRetry(field string, retry *builder.Retry, eventually bool) []fieldError {
+	if retry == nil {
+		return nil
+	}
+
+	var problems []fieldError
+	problem := func(name string, err error) {
+		problems = append(problems, fieldError{joinPath(field, name), err})
+	}
+
+	if retry.Attempts < 0 {
+		problem("attempts", fmt.Errorf("%v.attempts cannot be negative", field))
+	}
+
+	if retry.Interval < 0 {
+		problem("interval", fmt.Errorf("%v.interval cannot be negative", field))
+	}
+
+	if retry.Timeout < 0 {
+		problem("timeout", fmt.Errorf("%v.timeout cannot be negative", field))
+	}
+
+	if retry.Backoff != 0 && retry.Backoff < 1 {
+		problem("backoff", fmt.Errorf("%v.backoff must be at least 1", field))
+	}
+
+	for i, code := range retry.OnStatus {
+		if _, err := p.validateStatusCode(code); err != nil || code == 0 {
+			problem(fmt.Sprintf("onStatus[%v]", i), fmt.Errorf("%v.onStatus contains an invalid status code: %v", field, code))
+		}
+	}
+
+	if eventually && (len(retry.OnStatus) > 0 || retry.OnError) {
+		problems = append(problems, fieldError{field, fmt.Errorf("%v retries every failure, onStatus and onError cannot be given", field)})
+	} else if !eventually && len(retry.OnStatus) == 0 && !retry.OnError {
+		problems = append(problems, fieldError{field, fmt.Errorf("%v requires onStatus or onError", field)})
+	}
+
+	return problems
+}
+
 // validate is used to validate paramaters of an APITest and replace empty
-// paramaters with default/initialized values.
-func (p *Parser) validate(test builder.APITest) (builder.APITest, error) {
+// paramaters with default/initialized values. Every invalid field is returned.
+func (p *Parser) validate(test builder.APITest) (builder.APITest, []fieldError) {
+	var problems []fieldError
 	var err error
 
 	test.Endpoint, err = p.validateEndpoint(test.Endpoint)
 	if err != nil {
-		return test, err
+		problems = append(problems, fieldError{"endpoint", err})
 	}
 
 	test.Hostname, err = p.validateHostname(test.Hostname)
 	if err != nil {
-		return test, err
+		problems = append(problems, fieldError{"hostname", err})
 	}
 
 	test.Response.StatusCode, err = p.validateStatusCode(test.Response.StatusCode)
 	if err != nil {
-		return test, err
+		problems = append(problems, fieldError{"response.code", err})
 	}
 
 	test.Method, err = p.validateMethod(test.Method)
 	if err != nil {
-		return test, err
+		problems = append(problems, fieldError{"method", err})
+	}
+
+	// A test without auth uses the default, an empty auth disables it.
+	if test.Request.Auth == nil {
+		test.Request.Auth = p.conf.Auth
+	}
+
+	problems = append(problems, p.validateRequest(test.Request)...)
+	problems = append(problems, p.validateAuth(test.Request.Auth)...)
+	problems = append(problems, p.validateResponse(test.Method, test.Response)...)
+	problems = append(problems, p.validateHeaders(test.Response.Headers)...)
+	problems = append(problems, p.validateCookies(test.Response.Cookies)...)
+	problems = append(problems, p.validateRedirects(test)...)
+	problems = append(problems, p.validateRetry("retry", test.Retry, false)...)
+	problems = append(problems, p.validateRetry("eventually", test.Eventually, true)...)
+
+	if test.Retry != nil && test.Eventually != nil {
+		problems = append(problems, fieldError{"eventually", fmt.Errorf("eventually conflicts with retry, only one may be given")})
+	}
+
+	if test.ResetSession && test.Session == "" && !p.conf.FileSessions {
+		problems = append(problems, fieldError{"resetSession", fmt.Errorf("resetSession requires the test to belong to a session")})
 	}
 
-	return test, nil
+	return test, problems
 }
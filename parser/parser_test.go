@@ -1,9 +1,15 @@
 package parser
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/JonathonGore/api-check/builder"
 	"github.com/JonathonGore/api-check/config"
 )
 
@@ -142,3 +148,415 @@ func TestValidateStatusCode(t *testing.T) {
 		t.Errorf("Expected to receive an error for an invalid statuscode")
 	}
 }
+
+const yamlDefinitions = `
+# Comments are allowed in YAML test definitions.
+- description: create user
+  hostname: http://localhost:3000
+  endpoint: /users
+  method: post
+  request:
+    body: |
+      {"name": "Jack"}
+  response:
+    code: 201
+    json:
+      name: Jack
+      age: 21
+- description: count users
+  hostname: http://localhost:3000
+  endpoint: /users/count
+  method: get
+  request:
+    query-params:
+      page: 1
+      active: true
+  response:
+    code: 200
+    body: 42
+    headers:
+      Content-Length: 2
+      X-Flag: true
+`
+
+func TestParseYAMLFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "users.ac.yaml")
+	if err := ioutil.WriteFile(file, []byte(yamlDefinitions), 0644); err != nil {
+		t.Fatalf("unable to write test definitions: %v", err)
+	}
+
+	tests, err := p.ParseFile(file)
+	if err != nil {
+		t.Fatalf("Received unexpected error when parsing YAML file: %v", err)
+	}
+
+	if len(tests) != 2 {
+		t.Fatalf("Expected to parse 2 tests but received %v", len(tests))
+	}
+
+	test := tests[0]
+	if test.Method != http.MethodPost || test.Response.StatusCode != http.StatusCreated {
+		t.Errorf("Did not receive expected test: %+v", test)
+	}
+
+	if test.Request.Body != "{\"name\": \"Jack\"}\n" {
+		t.Errorf("Expected multi-line body to be preserved but received: %q", test.Request.Body)
+	}
+
+	// Numbers must be decoded the same way as JSON definitions.
+	expected := map[string]interface{}{"name": "Jack", "age": float64(21)}
+	if !reflect.DeepEqual(test.Response.JSON, expected) {
+		t.Errorf("Expected JSON %v but received %v", expected, test.Response.JSON)
+	}
+
+	// Scalars are decoded as strings wherever a string is expected.
+	test = tests[1]
+	params := map[string]string{"page": "1", "active": "true"}
+	if !reflect.DeepEqual(test.Request.QueryParams, params) {
+		t.Errorf("Expected query params %v but received %v", params, test.Request.QueryParams)
+	}
+
+	if test.Response.Body != "42" {
+		t.Errorf("Expected body 42 but received %q", test.Response.Body)
+	}
+
+	headers := map[string]builder.HeaderMatcher{
+		"Content-Length": builder.HeaderValue("2"),
+		"X-Flag":         builder.HeaderValue("true"),
+	}
+	if !reflect.DeepEqual(test.Response.Headers, headers) {
+		t.Errorf("Expected headers %+v but received %+v", headers, test.Response.Headers)
+	}
+}
+
+func TestParseYAMLFileMergeKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	definitions := `
+- &base
+  description: list users
+  hostname: http://localhost:3000
+  endpoint: /users
+  method: get
+  response:
+    code: 200
+- <<: *base
+  description: list admins
+  endpoint: /admins
+- <<: [*base, {method: post, hostname: http://localhost:4000}]
+  description: create user
+  response:
+    code: 201
+`
+
+	file := filepath.Join(dir, "users.ac.yaml")
+	if err := ioutil.WriteFile(file, []byte(definitions), 0644); err != nil {
+		t.Fatalf("unable to write test definitions: %v", err)
+	}
+
+	// Merge keys must not be reported as unknown fields.
+	strict := New(config.Config{})
+	strict.Strict(true)
+
+	tests, err := strict.ParseFile(file)
+	if err != nil {
+		t.Fatalf("Received unexpected error when parsing YAML file: %v", err)
+	}
+
+	if len(tests) != 3 {
+		t.Fatalf("Expected to parse 3 tests but received %v", len(tests))
+	}
+
+	// Explicit keys take precedence over merged ones, and earlier merged
+	// mappings over later ones.
+	expected := []struct {
+		description, hostname, endpoint, method string
+		code                                    int
+	}{
+		{"list users", "http://localhost:3000", "/users", http.MethodGet, 200},
+		{"list admins", "http://localhost:3000", "/admins", http.MethodGet, 200},
+		{"create user", "http://localhost:3000", "/users", http.MethodGet, 201},
+	}
+
+	for i, e := range expected {
+		test := tests[i]
+		if test.Description != e.description || test.Hostname != e.hostname || test.Endpoint != e.endpoint ||
+			!strings.EqualFold(test.Method, e.method) || test.Response.StatusCode != e.code {
+			t.Errorf("Expected %+v but received %+v", e, test)
+		}
+	}
+}
+
+func TestParseYAMLFileHeaderError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	definitions := `
+- description: invalid header
+  hostname: http://localhost:3000
+  endpoint: /users
+  method: get
+  response:
+    code: 200
+    headers:
+      X-Flag: [{}]
+`
+
+	file := filepath.Join(dir, "users.ac.yaml")
+	if err := ioutil.WriteFile(file, []byte(definitions), 0644); err != nil {
+		t.Fatalf("unable to write test definitions: %v", err)
+	}
+
+	_, err = p.ParseFile(file)
+
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Expected a single parse error but received %v", err)
+	}
+
+	// The error must be reported at the header rather than the test.
+	if errs[0].Line != 9 || errs[0].Column != 7 {
+		t.Errorf("Expected error at 9:7 but received %v:%v", errs[0].Line, errs[0].Column)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	var typeOf interface{} = "string"
+	zero, length, negative := int64(0), int64(2), int64(-1)
+
+	tests := []struct {
+		method   string
+		response builder.APIResponse
+		expected []string
+	}{
+		{http.MethodGet, builder.APIResponse{StatusCode: 200, Body: "ok"}, nil},
+		{http.MethodGet, builder.APIResponse{StatusCode: 204}, nil},
+		{http.MethodHead, builder.APIResponse{StatusCode: 200}, nil},
+		{http.MethodGet, builder.APIResponse{StatusCode: 200, Body: "ok", JSON: "ok"}, []string{"response.json"}},
+		{http.MethodGet, builder.APIResponse{StatusCode: 200, JSON: "ok", TypeOf: &typeOf}, []string{"response.ofType"}},
+		{http.MethodHead, builder.APIResponse{StatusCode: 200, Body: "ok"}, []string{"response.body"}},
+		{http.MethodDelete, builder.APIResponse{StatusCode: 204, JSON: "ok"}, []string{"response.json"}},
+		{http.MethodGet, builder.APIResponse{StatusCode: 304, TypeOf: &typeOf}, []string{"response.ofType"}},
+		{http.MethodHead, builder.APIResponse{StatusCode: 204, EmptyBody: true, ContentLength: &zero}, nil},
+		{http.MethodGet, builder.APIResponse{StatusCode: 200, EmptyBody: true, Body: "ok"}, []string{"response.body"}},
+		{http.MethodGet, builder.APIResponse{StatusCode: 200, EmptyBody: true, ContentLength: &length}, []string{"response.contentLength"}},
+		{http.MethodGet, builder.APIResponse{StatusCode: 200, ContentLength: &negative}, []string{"response.contentLength"}},
+	}
+
+	for _, test := range tests {
+		var fields []string
+		for _, problem := range p.validateResponse(test.method, test.response) {
+			fields = append(fields, problem.field)
+		}
+
+		if !reflect.DeepEqual(fields, test.expected) {
+			t.Errorf("Expected problems with %v but received %v for %+v", test.expected, fields, test.response)
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	if problems := p.validateRequest(builder.APIRequest{Body: "{}"}); len(problems) != 0 {
+		t.Errorf("Received unexpected problems when validating request: %v", problems)
+	}
+
+	if problems := p.validateRequest(builder.APIRequest{Body: "{}", JSON: map[string]interface{}{}}); len(problems) != 1 {
+		t.Errorf("Expected a conflict between body and json but received: %v", problems)
+	}
+}
+
+func TestValidateHeaders(t *testing.T) {
+	headers := map[string]builder.HeaderMatcher{
+		"Content-Type":  builder.HeaderValue("application/json"),
+		"Cache-Control": {Contains: "no-store", Regex: "^private"},
+		"Server":        {Absent: true},
+		"Empty":         {},
+		"Conflicting":   {Absent: true, Contains: "no-store"},
+		"Invalid":       {Regex: "("},
+	}
+
+	var fields []string
+	for _, problem := range p.validateHeaders(headers) {
+		fields = append(fields, problem.field)
+	}
+
+	expected := []string{"response.headers.Conflicting", "response.headers.Empty", "response.headers.Invalid"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected problems with %v but received %v", expected, fields)
+	}
+}
+
+func TestValidateCookies(t *testing.T) {
+	yes, negative := true, -1
+
+	cookies := map[string]builder.CookieAssertion{
+		"session":   {HTTPOnly: &yes, SameSite: "lax", Pattern: "^[a-z]+$"},
+		"deleted":   {Absent: true},
+		"secure":    {Absent: true, Secure: &yes},
+		"sameSite":  {SameSite: "sometimes"},
+		"pattern":   {Pattern: "("},
+		"expiresIn": {MaxAge: &negative},
+	}
+
+	var fields []string
+	for _, problem := range p.validateCookies(cookies) {
+		fields = append(fields, problem.field)
+	}
+
+	expected := []string{
+		"response.cookies.expiresIn.maxAge",
+		"response.cookies.pattern.pattern",
+		"response.cookies.sameSite.sameSite",
+		"response.cookies.secure",
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected problems with %v but received %v", expected, fields)
+	}
+}
+
+func TestParseFileSessions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "users.ac.json")
+	contents := `[{"endpoint": "/login"}, {"endpoint": "/me", "session": "admin"}, {"endpoint": "/me", "resetSession": true}]`
+	if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatalf("unable to write test definitions: %v", err)
+	}
+
+	// Without file sessions only tests naming a session may reset it.
+	p := New(config.Config{Hostname: "http://localhost"})
+	if _, err := p.ParseFile(file); err == nil {
+		t.Errorf("Expected an error resetting a test without a session")
+	}
+
+	p = New(config.Config{Hostname: "http://localhost", FileSessions: true})
+	tests, err := p.ParseFile(file)
+	if err != nil {
+		t.Fatalf("Received unexpected error when parsing file: %v", err)
+	}
+
+	sessions := []string{tests[0].Session, tests[1].Session, tests[2].Session}
+	if expected := []string{file, "admin", file}; !reflect.DeepEqual(sessions, expected) {
+		t.Errorf("Expected sessions %v but received %v", expected, sessions)
+	}
+}
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		auth     *builder.Auth
+		expected []string
+	}{
+		{nil, nil},
+		{&builder.Auth{}, nil},
+		{&builder.Auth{Bearer: "${ENV:TOKEN}"}, nil},
+		{&builder.Auth{Basic: &builder.BasicAuth{User: "jack"}}, nil},
+		{&builder.Auth{Basic: &builder.BasicAuth{Pass: "pass"}}, []string{"request.auth.basic"}},
+		{&builder.Auth{Bearer: "token", APIKey: &builder.APIKeyAuth{In: "query", Name: "key"}}, []string{"request.auth.apiKey"}},
+		{&builder.Auth{APIKey: &builder.APIKeyAuth{In: "cookie"}}, []string{"request.auth.apiKey.in", "request.auth.apiKey"}},
+		{&builder.Auth{OAuth2: &builder.OAuth2Auth{TokenURL: "https://auth.example.com/token", ClientID: "client"}}, nil},
+		{&builder.Auth{OAuth2: &builder.OAuth2Auth{TokenURL: "${ENV:TOKEN_URL}", ClientID: "client"}}, nil},
+		{&builder.Auth{OAuth2: &builder.OAuth2Auth{TokenURL: "token"}}, []string{"request.auth.oauth2.tokenUrl", "request.auth.oauth2"}},
+		{&builder.Auth{Bearer: "token", OAuth2: &builder.OAuth2Auth{TokenURL: "https://auth.example.com/token", ClientID: "client"}}, []string{"request.auth.oauth2"}},
+	}
+
+	for _, test := range tests {
+		var fields []string
+		for _, problem := range p.validateAuth(test.auth) {
+			fields = append(fields, problem.field)
+		}
+
+		if !reflect.DeepEqual(fields, test.expected) {
+			t.Errorf("Expected problems with %v but received %v for %+v", test.expected, fields, test.auth)
+		}
+	}
+}
+
+func TestValidateDefaultAuth(t *testing.T) {
+	auth := &builder.Auth{Bearer: "token"}
+	p := New(config.Config{Hostname: "http://localhost", Auth: auth})
+
+	test, _ := p.validate(builder.APITest{})
+	if test.Request.Auth != auth {
+		t.Errorf("Expected the default auth to be used but received %+v", test.Request.Auth)
+	}
+
+	// An empty auth disables the default.
+	test, _ = p.validate(builder.APITest{Request: builder.APIRequest{Auth: &builder.Auth{}}})
+	if test.Request.Auth == auth {
+		t.Errorf("Expected an empty auth to override the default")
+	}
+}
+
+func TestValidateRedirects(t *testing.T) {
+	follow, stay := true, false
+	hops := []builder.RedirectHop{{StatusCode: http.StatusFound}, {StatusCode: http.StatusOK}}
+
+	tests := []struct {
+		conf     config.Config
+		follow   *bool
+		expected []string
+	}{
+		{config.Config{}, nil, []string{"response.redirects[1].code"}},
+		{config.Config{}, &stay, []string{"response.redirects", "response.redirects[1].code"}},
+		{config.Config{HTTP: config.HTTP{Redirects: config.RedirectsNone}}, nil, []string{"response.redirects", "response.redirects[1].code"}},
+		{config.Config{HTTP: config.HTTP{Redirects: config.RedirectsNone}}, &follow, []string{"response.redirects[1].code"}},
+	}
+
+	for _, test := range tests {
+		p := New(test.conf)
+
+		var fields []string
+		for _, problem := range p.validateRedirects(builder.APITest{FollowRedirects: test.follow, Response: builder.APIResponse{Redirects: hops}}) {
+			fields = append(fields, problem.field)
+		}
+
+		if !reflect.DeepEqual(fields, test.expected) {
+			t.Errorf("Expected problems with %v but received %v", test.expected, fields)
+		}
+	}
+}
+
+func TestValidateRetry(t *testing.T) {
+	tests := []struct {
+		retry      *builder.Retry
+		eventually bool
+		expected   []string
+	}{
+		{nil, false, nil},
+		{&builder.Retry{OnStatus: []int{502, 503}}, false, nil},
+		{&builder.Retry{OnError: true, Attempts: 5, Backoff: 1.5}, false, nil},
+		{&builder.Retry{}, true, nil},
+		{&builder.Retry{}, false, []string{"retry"}},
+		{&builder.Retry{OnError: true}, true, []string{"retry"}},
+		{&builder.Retry{Attempts: -1, Backoff: 0.5, OnStatus: []int{0, 999}}, false, []string{"retry.attempts", "retry.backoff", "retry.onStatus[0]", "retry.onStatus[1]"}},
+		{&builder.Retry{Interval: -1, Timeout: -1}, true, []string{"retry.interval", "retry.timeout"}},
+	}
+
+	for _, test := range tests {
+		var fields []string
+		for _, problem := range p.validateRetry("retry", test.retry, test.eventually) {
+			fields = append(fields, problem.field)
+		}
+
+		if !reflect.DeepEqual(fields, test.expected) {
+			t.Errorf("Expected problems with %v but received %v for %+v", test.expected, fields, test.retry)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// position is a location within a test definition file. Lines and columns
+// both start at 1.
+type position struct {
+	line   int
+	column int
+}
+
+// positions maps the path of each value in a test definition file to where
+// it is defined. Paths use the same form as unknown fields prefixed with the
+// index of the test, for example "[6].response.code". Object members are
+// located by their key.
+type positions map[string]position
+
+// lookup finds the position of the value at path. When the value itself is
+// not defined, such as a missing required field, the position of the closest
+// enclosing value is used.
+func (p positions) lookup(path string) (position, bool) {
+	for {
+		if pos, ok := p[path]; ok {
+			return pos, true
+		}
+
+		i := strings.LastIndexAny(path, ".[")
+		if i <= 0 {
+			pos, ok := p[path]
+			return pos, ok
+		}
+
+		path = path[:i]
+	}
+}
+
+// offsetPosition converts a byte offset within contents to a position.
+func offsetPosition(contents []byte, offset int64) position {
+	if offset > int64(len(contents)) {
+		offset = int64(len(contents))
+	}
+
+	before := contents[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return position{line: line, column: column}
+}
+
+// jsonWalker records the position of every value of a JSON document.
+type jsonWalker struct {
+	contents  []byte
+	dec       *json.Decoder
+	positions positions
+}
+
+// start produces the offset at which the next token begins, skipping any
+// whitespace and separators the decoder has not consumed yet.
+func (w *jsonWalker) start() int64 {
+	offset := w.dec.InputOffset()
+	for offset < int64(len(w.contents)) && strings.IndexByte(" \t\r\n,:", w.contents[offset]) >= 0 {
+		offset++
+	}
+
+	return offset
+}
+
+// record stores the position of path unless it is already known.
+func (w *jsonWalker) record(path string, offset int64) {
+	if _, ok := w.positions[path]; !ok {
+		w.positions[path] = offsetPosition(w.contents, offset)
+	}
+}
+
+// value walks the next value in the document, which is found at path.
+func (w *jsonWalker) value(path string) error {
+	w.record(path, w.start())
+
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for w.dec.More() {
+			offset := w.start()
+
+			key, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+
+			member := joinPath(path, fmt.Sprintf("%v", key))
+			w.record(member, offset)
+
+			if err := w.value(member); err != nil {
+				return err
+			}
+		}
+
+		_, err = w.dec.Token() // Consume the closing '}'
+	case json.Delim('['):
+		for i := 0; w.dec.More(); i++ {
+			if err := w.value(fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+
+		_, err = w.dec.Token() // Consume the closing ']'
+	}
+
+	return err
+}
+
+// jsonPositions finds the position of every value in a JSON document. A
+// malformed document produces the positions found before the problem.
+func jsonPositions(contents []byte) positions {
+	w := jsonWalker{
+		contents:  contents,
+		dec:       json.NewDecoder(bytes.NewReader(contents)),
+		positions: make(positions),
+	}
+	w.value("")
+
+	return w.positions
+}
+
+// walkYAML records the position of the node found at path and every node
+// below it.
+func walkYAML(node *yaml.Node, path string, p positions) {
+	if _, ok := p[path]; !ok {
+		p[path] = position{line: node.Line, column: node.Column}
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkYAML(child, path, p)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkYAML(child, fmt.Sprintf("%v[%v]", path, i), p)
+		}
+	case yaml.MappingNode:
+		// Content alternates between each key and its value.
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			member := joinPath(path, key.Value)
+
+			p[member] = position{line: key.Line, column: key.Column}
+			walkYAML(value, member, p)
+		}
+	}
+}
+
+// yamlPositions finds the position of every value in a YAML document.
+func yamlPositions(contents []byte) positions {
+	p := make(positions)
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(contents, &node); err == nil {
+		walkYAML(&node, "", p)
+	}
+
+	return p
+}
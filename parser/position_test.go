@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JonathonGore/api-check/config"
+)
+
+func TestJSONPositions(t *testing.T) {
+	contents := []byte("[\n  {\n    \"endpoint\": \"/users\",\n    \"response\": {\"code\": 200}\n  }\n]")
+	pos := jsonPositions(contents)
+
+	tests := []struct {
+		path     string
+		expected position
+	}{
+		{"[0]", position{2, 3}},
+		{"[0].endpoint", position{3, 5}},
+		{"[0].response.code", position{4, 18}},
+		{"[0].response.missing", position{4, 5}},
+	}
+
+	for _, test := range tests {
+		if p, ok := pos.lookup(test.path); !ok || p != test.expected {
+			t.Errorf("Expected %v to be at %v but received %v", test.path, test.expected, p)
+		}
+	}
+}
+
+func TestYAMLPositions(t *testing.T) {
+	contents := []byte("- endpoint: /users\n  response:\n    code: 200\n")
+	pos := yamlPositions(contents)
+
+	tests := []struct {
+		path     string
+		expected position
+	}{
+		{"[0]", position{1, 3}},
+		{"[0].endpoint", position{1, 3}},
+		{"[0].response.code", position{3, 5}},
+	}
+
+	for _, test := range tests {
+		if p, ok := pos.lookup(test.path); !ok || p != test.expected {
+			t.Errorf("Expected %v to be at %v but received %v", test.path, test.expected, p)
+		}
+	}
+}
+
+func TestParseFileErrorPositions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		contents string
+		expected []string
+	}{
+		{
+			"syntax.ac.json",
+			"[\n  {\"endpoint\": \"/users\",}\n]",
+			[]string{"syntax.ac.json:2:26: invalid character '}' looking for beginning of object key string"},
+		},
+		{
+			"invalid.ac.json",
+			"[\n  {\"hostname\": \"http://localhost\", \"endpoint\": \"/users\"},\n  {\n    \"hostname\": \"http://localhost\",\n    \"endpoint\": \"/users\",\n    \"response\": {\"code\": 999},\n    \"method\": \"FETCH\"\n  },\n  {\"endpoint\": 12}\n]",
+			[]string{
+				"invalid.ac.json:6:18: error in test #2: HTTP status code out of range",
+				"invalid.ac.json:7:5: error in test #2: received unsupport http method: FETCH",
+				"invalid.ac.json:9:4: error in test #3: invalid value for endpoint: expected string but found number",
+			},
+		},
+		{
+			"invalid.ac.yaml",
+			"- hostname: http://localhost\n  endpoint: /users\n  response:\n    code: 999\n",
+			[]string{"invalid.ac.yaml:4:5: error in test #1: HTTP status code out of range"},
+		},
+	}
+
+	for _, test := range tests {
+		file := filepath.Join(dir, test.name)
+		if err := ioutil.WriteFile(file, []byte(test.contents), 0644); err != nil {
+			t.Fatalf("unable to write test definitions: %v", err)
+		}
+
+		p := New(config.Config{})
+		_, err := p.ParseFile(file)
+		errs, ok := err.(Errors)
+		if !ok || len(errs) != len(test.expected) {
+			t.Errorf("Expected %v errors for %v but received: %v", len(test.expected), test.name, err)
+			continue
+		}
+
+		for i, e := range errs {
+			if msg := e.Error(); msg != filepath.Join(dir, test.expected[i]) {
+				t.Errorf("Expected error %v but received %v", test.expected[i], msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unmarshalerType is used to detect types which decode themselves, their
+// contents are not checked for unknown fields.
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownField describes a key in a test definition that does not match any
+// field of the type it is decoded into.
+type unknownField struct {
+	// test is the index of the test containing the field.
+	test int
+
+	// path is the dotted path of the field within the test.
+	path string
+
+	// suggestion is the closest known field name, empty if none are close.
+	suggestion string
+}
+
+// Error describes the unknown field and suggests a replacement if possible.
+func (f unknownField) Error() string {
+	msg := fmt.Sprintf("unknown field %q", f.path)
+	if f.suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", f.suggestion)
+	}
+
+	return msg
+}
+
+// jsonFields produces the JSON names of every field of the given struct type
+// mapped to the field's type.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // Unexported
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		} else if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+// lookupField finds the field matching key. Like encoding/json an exact
+// match is preferred but the match is otherwise case insensitive.
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return t, true
+	}
+
+	for name, t := range fields {
+		if strings.EqualFold(name, key) {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// levenshtein computes the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			// Cheapest of a deletion, insertion or substitution.
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+
+		prev = curr
+	}
+
+	return prev[len(b)]
+}
+
+// suggest produces the known field name closest to key, or an empty string
+// if no field is similar enough to be a likely typo.
+func suggest(fields map[string]reflect.Type, key string) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Ensures ties are always broken the same way.
+
+	best, bestDistance := "", len(key)/2+1
+	for _, name := range names {
+		if d := levenshtein(strings.ToLower(key), strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+
+	return best
+}
+
+// joinPath appends a field name to a dotted path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// sortedKeys produces the keys of a JSON object in sorted order, so unknown
+// fields are always reported in the same order.
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// findUnknownFields walks a decoded JSON value alongside the type it decodes
+// into, producing every object key that does not match a struct field.
+func findUnknownFields(value interface{}, t reflect.Type, path string) []unknownField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var unknown []unknownField
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		fields := jsonFields(t)
+		for _, key := range sortedKeys(object) {
+			fieldType, ok := lookupField(fields, key)
+			if !ok {
+				unknown = append(unknown, unknownField{
+					path:       joinPath(path, key),
+					suggestion: suggest(fields, key),
+				})
+				continue
+			}
+
+			unknown = append(unknown, findUnknownFields(object[key], fieldType, joinPath(path, key))...)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		for _, key := range sortedKeys(object) {
+			unknown = append(unknown, findUnknownFields(object[key], t.Elem(), joinPath(path, key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+
+		for i, val := range values {
+			unknown = append(unknown, findUnknownFields(val, t.Elem(), fmt.Sprintf("%v[%v]", path, i))...)
+		}
+	}
+
+	return unknown
+}
+
+// unmarshalError finds the path of the first value within value, which decodes
+// into t, that fails to decode itself using json.Unmarshaler.
+func unmarshalError(value interface{}, t reflect.Type, path string) (string, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return path, true
+		}
+
+		return path, json.Unmarshal(data, reflect.New(t).Interface()) != nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, _ := value.(map[string]interface{})
+
+		fields := jsonFields(t)
+		for _, key := range sortedKeys(object) {
+			if fieldType, ok := lookupField(fields, key); ok {
+				if field, ok := unmarshalError(object[key], fieldType, joinPath(path, key)); ok {
+					return field, true
+				}
+			}
+		}
+	case reflect.Map:
+		object, _ := value.(map[string]interface{})
+
+		for _, key := range sortedKeys(object) {
+			if field, ok := unmarshalError(object[key], t.Elem(), joinPath(path, key)); ok {
+				return field, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		values, _ := value.([]interface{})
+
+		for i, val := range values {
+			if field, ok := unmarshalError(val, t.Elem(), fmt.Sprintf("%v[%v]", path, i)); ok {
+				return field, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// findUnmarshalError finds the path of the field within a single encoded test
+// which caused decoding it into t to fail, if a json.Unmarshaler caused it.
+func findUnmarshalError(message []byte, t reflect.Type) (string, bool) {
+	var value interface{}
+	if err := json.Unmarshal(message, &value); err != nil {
+		return "", false
+	}
+
+	return unmarshalError(value, t, "")
+}
+
+// checkUnknownFields produces every unknown field of each test in a decoded
+// test definition file.
+func checkUnknownFields(contents []byte, t reflect.Type) ([]unknownField, error) {
+	var values []interface{}
+	if err := json.Unmarshal(contents, &values); err != nil {
+		return nil, err
+	}
+
+	var unknown []unknownField
+	for i, value := range values {
+		for _, field := range findUnknownFields(value, t, "") {
+			field.test = i
+			unknown = append(unknown, field)
+		}
+	}
+
+	return unknown, nil
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+	"github.com/JonathonGore/api-check/config"
+)
+
+var levenshteinTests = []struct {
+	a, b     string
+	distance int
+}{
+	{"", "", 0},
+	{"response", "response", 0},
+	{"reponse", "response", 1},
+	{"heders", "headers", 1},
+	{"kitten", "sitting", 3},
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, test := range levenshteinTests {
+		if d := levenshtein(test.a, test.b); d != test.distance {
+			t.Errorf("expected distance between %v and %v to be %v but received %v", test.a, test.b, test.distance, d)
+		}
+	}
+}
+
+const unknownFieldsJSON = `[
+	{"endpoint": "/", "Method": "get", "response": {"code": 200}},
+	{"endpoint": "/", "reponse": {"code": 200}},
+	{"endpoint": "/", "response": {"heders": {}, "json": {"anything": "goes"}}, "zzzzzz": true}
+]`
+
+func TestCheckUnknownFields(t *testing.T) {
+	unknown, err := checkUnknownFields([]byte(unknownFieldsJSON), reflect.TypeOf(builder.APITest{}))
+	if err != nil {
+		t.Fatalf("Received unexpected error: %v", err)
+	}
+
+	expected := []unknownField{
+		{test: 1, path: "reponse", suggestion: "response"},
+		{test: 2, path: "response.heders", suggestion: "headers"},
+		{test: 2, path: "zzzzzz"},
+	}
+
+	if !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("Expected %+v but received %+v", expected, unknown)
+	}
+
+	if msg := expected[0].Error(); msg != `unknown field "reponse", did you mean "response"?` {
+		t.Errorf("Received unexpected error message: %v", msg)
+	}
+}
+
+func TestParseFileUnknownFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "users.ac.json")
+	contents := `[{"hostname": "http://localhost", "reponse": {"code": 201}}]`
+	if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatalf("unable to write test definitions: %v", err)
+	}
+
+	// Without strict parsing unknown fields are only warnings.
+	lenient := New(config.Config{})
+	if _, err := lenient.ParseFile(file); err != nil {
+		t.Errorf("Received unexpected error when parsing file: %v", err)
+	}
+
+	if len(lenient.Warnings()) != 1 {
+		t.Errorf("Expected a single warning but received: %v", lenient.Warnings())
+	}
+
+	strict := New(config.Config{})
+	strict.Strict(true)
+	if _, err := strict.ParseFile(file); err == nil {
+		t.Errorf("Expected unknown field to be an error when parsing strictly")
+	}
+}
@@ -2,15 +2,80 @@ package printer
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/JonathonGore/api-check/builder"
 	"github.com/JonathonGore/api-check/runner"
 )
 
-// buildDescription builds the describing text to use when printing the run
-// results. If the individual test has a description it is used otherwise
+// ANSI escape sequences used when printing in color.
+const (
+	reset     = "\033[0m"
+	bold      = "\033[1m"
+	dim       = "\033[2m"
+	red       = "\033[31m"
+	green     = "\033[32m"
+	yellow    = "\033[33m"
+	clearLine = "\r\033[K"
+)
+
+// Printer prints the results of a test run to a terminal.
+type Printer struct {
+	out io.Writer
+
+	// color determines if output is decorated with ANSI colors.
+	color bool
+
+	// progress determines if a live progress counter is shown while tests run.
+	progress bool
+}
+
+// New creates a Printer writing to out. Color and progress output should only
+// be enabled when out is a terminal.
+func New(out io.Writer, color, progress bool) *Printer {
+	return &Printer{
+		out:      out,
+		color:    color,
+		progress: progress,
+	}
+}
+
+// NewTerminal creates a Printer writing to stdout. Colors and a live progress
+// counter are enabled when stdout is a terminal, both can additionally be
+// disabled for plain output with noColor or by setting the NO_COLOR environment
+// variable.
+func NewTerminal(noColor bool) *Printer {
+	fancy := isTerminal(os.Stdout) && !noColor && os.Getenv("NO_COLOR") == ""
+
+	return New(os.Stdout, fancy, fancy)
+}
+
+// isTerminal determines if the given file is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps text in the given ANSI style when color is enabled.
+func (p *Printer) paint(style, text string) string {
+	if !p.color {
+		return text
+	}
+
+	return style + text + reset
+}
+
+// Description builds the describing text to use when printing the run
+// results or naming a test. If the individual test has a description it is used otherwise
 // that hostname and endpoint are used.
-func buildDescription(test builder.APITest) string {
+func Description(test builder.APITest) string {
 	if len(test.Description) != 0 {
 		return test.Description
 	}
@@ -28,38 +93,198 @@ func succeededText(succeeded bool) string {
 	return "failed"
 }
 
+// marker produces the symbol printed in front of each test result.
+func (p *Printer) marker(report runner.RunReport) string {
+	skipped := report.Status == runner.StatusSkipped
+	flaky := report.Status == runner.StatusFlaky
+	succeeded := report.Successful
+
+	if !p.color {
+		if skipped || flaky {
+			return fmt.Sprintf("%-9v", report.Status)
+		}
+		return fmt.Sprintf("%-9v", succeededText(succeeded))
+	}
+
+	if skipped {
+		return p.paint(yellow, "-")
+	}
+
+	if flaky {
+		return p.paint(yellow, "!")
+	}
+
+	if succeeded {
+		return p.paint(green, "✓")
+	}
+
+	return p.paint(red, "✗")
+}
+
+// displayFile converts a test definition filename into the form it is
+// printed in, preferring a path relative to the working directory.
+func displayFile(file string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+
+	return file
+}
+
+// indent prefixes the first line of text with first and every following
+// non-empty line with rest.
+func indent(text, first, rest string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if i == 0 {
+			lines[i] = first + line
+		} else if line != "" {
+			lines[i] = rest + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Start prints the header shown before any test is run.
+func (p *Printer) Start(total int) {
+	fmt.Fprintf(p.out, "Running go api-check\n\n")
+}
+
+// Progress prints a live counter of how many tests have completed. It is a
+// no-op unless progress output is enabled.
+func (p *Printer) Progress(report runner.RunReport, done, total int) {
+	if !p.progress {
+		return
+	}
+
+	fmt.Fprintf(p.out, "%vRunning tests %v/%v", clearLine, done, total)
+}
+
 // printStats prints the statistics from all tests that were run. Describing
-// how many tests ran and how many failed/succeeded.
-func printStats(successes, failures int) {
-	total := successes + failures
+// how many tests ran and how many failed/succeeded/were flaky/were skipped.
+func (p *Printer) printStats(successes, failures, flaky, skipped int) {
+	total := successes + failures + flaky
+
+	summary := fmt.Sprintf("%v tests ran. %v successful. %v failures.", total, successes, failures)
+	if flaky > 0 {
+		summary += fmt.Sprintf(" %v flaky.", flaky)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(" %v skipped.", skipped)
+	}
+
+	if failures > 0 {
+		summary = p.paint(bold+red, summary)
+	} else {
+		summary = p.paint(bold+green, summary)
+	}
 
-	fmt.Printf("\n%v tests ran. %v successful. %v failures.\n", total, successes, failures)
+	fmt.Fprintf(p.out, "\n%v\n", summary)
 }
 
-// printReport consumes a RunReport for a specific test and prints information
-// regarding its success or failure.
-func printReport(report runner.RunReport) {
-	fmt.Printf("API Check Test for: %v %v\n", buildDescription(report.Test), succeededText(report.Successful))
-	if !report.Successful {
-		fmt.Printf("Failure reason: %v\n", report.Error)
+// printReport consumes a RunReport for a specific test and prints a single
+// line describing its success or failure.
+func (p *Printer) printReport(report runner.RunReport) {
+	line := fmt.Sprintf("  %v %v", p.marker(report), Description(report.Test))
+	if report.Status == runner.StatusSkipped {
+		line += p.paint(dim, fmt.Sprintf(" (%v)", report.Test.Skip))
 	}
+
+	if report.Status == runner.StatusFlaky {
+		line += p.paint(dim, fmt.Sprintf(" (passed after %v reruns)", report.Reruns))
+	}
+
+	if report.Attempts > 1 {
+		line += p.paint(dim, fmt.Sprintf(" (%v attempts)", report.Attempts))
+	}
+
+	fmt.Fprintf(p.out, "%v\n", line)
 }
 
-// PrintReports consumes a slice of run reports and iterates through them
-// printing results of each and printing aggregate result at the end.
-func PrintReports(reports []runner.RunReport) {
-	successes := 0
-	errors := 0
+// printFailure prints every failure reason for a failed test as part of the
+// failures recap.
+func (p *Printer) printFailure(n int, report runner.RunReport) {
+	title := Description(report.Test)
+	if report.Test.File != "" {
+		title = displayFile(report.Test.File) + " > " + title
+	}
+
+	fmt.Fprintf(p.out, "\n  %v) %v\n", n, p.paint(bold, title))
 
+	// Assertion failures are listed individually so they can all be fixed at once.
+	if len(report.Failures) == 0 {
+		fmt.Fprintf(p.out, "%v\n", p.paint(red, indent(fmt.Sprintf("%v", report.Error), "     ", "     ")))
+		return
+	}
+
+	for _, failure := range report.Failures {
+		fmt.Fprintf(p.out, "%v\n", p.paint(red, indent(failure.Error(), "     - ", "       ")))
+	}
+}
+
+// PrintReports consumes a slice of run reports and prints the result of each
+// grouped by the file it was defined in, followed by a recap of every failure
+// and the aggregate results.
+func (p *Printer) PrintReports(reports []runner.RunReport) {
+	if p.progress {
+		fmt.Fprint(p.out, clearLine)
+	}
+
+	// Group reports by file while preserving the order they were run in.
+	var files []string
+	groups := make(map[string][]runner.RunReport)
 	for _, report := range reports {
-		printReport(report)
+		if _, ok := groups[report.Test.File]; !ok {
+			files = append(files, report.Test.File)
+		}
+		groups[report.Test.File] = append(groups[report.Test.File], report)
+	}
+
+	for _, file := range files {
+		if file != "" {
+			fmt.Fprintf(p.out, "%v\n", p.paint(dim, displayFile(file)))
+		}
+
+		for _, report := range groups[file] {
+			p.printReport(report)
+		}
+	}
 
-		if report.Error != nil {
-			errors++
+	successes, flaky, skipped := 0, 0, 0
+	var failed []runner.RunReport
+
+	for _, report := range reports {
+		if report.Status == runner.StatusSkipped {
+			skipped++
+		} else if report.Status == runner.StatusFlaky {
+			flaky++
+		} else if report.Error != nil {
+			failed = append(failed, report)
 		} else {
 			successes++
 		}
 	}
 
-	printStats(successes, errors)
+	if len(failed) > 0 {
+		fmt.Fprintf(p.out, "\n%v\n", p.paint(bold, "Failures:"))
+		for i, report := range failed {
+			p.printFailure(i+1, report)
+		}
+	}
+
+	p.printStats(successes, len(failed), flaky, skipped)
+}
+
+// Report prints every report once the run has finished, see PrintReports.
+func (p *Printer) Report(reports []runner.RunReport) {
+	p.PrintReports(reports)
+}
+
+// PrintReports prints the given reports to stdout, using colors and progress
+// output only when stdout is a terminal.
+func PrintReports(reports []runner.RunReport) {
+	NewTerminal(false).PrintReports(reports)
 }
@@ -1,9 +1,13 @@
 package printer
 
 import (
+	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/JonathonGore/api-check/builder"
+	"github.com/JonathonGore/api-check/runner"
 )
 
 func TestSucceededText(t *testing.T) {
@@ -16,7 +20,7 @@ func TestSucceededText(t *testing.T) {
 	}
 }
 
-var buildDescriptionTests = []struct {
+var descriptionTests = []struct {
 	test   builder.APITest
 	result string
 }{
@@ -25,10 +29,43 @@ var buildDescriptionTests = []struct {
 	{builder.APITest{Hostname: "localhost", Endpoint: "/apps"}, "localhost/apps"},
 }
 
-func TestBuildDescription(t *testing.T) {
-	for _, test := range buildDescriptionTests {
-		if result := buildDescription(test.test); result != test.result {
+func TestDescription(t *testing.T) {
+	for _, test := range descriptionTests {
+		if result := Description(test.test); result != test.result {
 			t.Errorf("expected %v but received %v", test.result, result)
 		}
 	}
 }
+
+func TestPrintReports(t *testing.T) {
+	reports := []runner.RunReport{
+		{Test: builder.APITest{Description: "first", File: "/tmp/users.ac.json"}, Successful: true},
+		{Test: builder.APITest{Description: "second", File: "/tmp/apps.ac.json"}, Successful: true, Attempts: 3},
+		{
+			Test:     builder.APITest{Description: "third", File: "/tmp/users.ac.json"},
+			Error:    runner.AssertionErrors{errors.New("bad status"), errors.New("bad body")},
+			Failures: runner.AssertionErrors{errors.New("bad status"), errors.New("bad body")},
+		},
+		{Test: builder.APITest{Description: "fourth", Skip: "flaky"}, Status: runner.StatusSkipped},
+		{Test: builder.APITest{Description: "fifth", File: "/tmp/apps.ac.json"}, Successful: true, Status: runner.StatusFlaky, Reruns: 1},
+	}
+
+	var out bytes.Buffer
+	New(&out, false, false).PrintReports(reports)
+	result := out.String()
+
+	if strings.Contains(result, "\033[") {
+		t.Errorf("expected plain output to contain no escape sequences: %q", result)
+	}
+
+	// Tests from the same file should be grouped together.
+	if strings.Index(result, "third") > strings.Index(result, "apps.ac.json") {
+		t.Errorf("expected tests to be grouped by file: %v", result)
+	}
+
+	for _, expected := range []string{"- bad status", "- bad body", "fourth (flaky)", "second (3 attempts)", "flaky     fifth (passed after 1 reruns)", "4 tests ran. 2 successful. 1 failures. 1 flaky. 1 skipped."} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("expected output to contain %q: %v", expected, result)
+		}
+	}
+}
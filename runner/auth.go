@@ -0,0 +1,170 @@
+package runner
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// redacted replaces secrets in the errors of a report.
+const redacted = "[REDACTED]"
+
+// minRedactedLength is the length of the shortest secret which is redacted.
+// Replacing shorter values would garble every error while hiding very little.
+const minRedactedLength = 4
+
+// envPattern matches references to environment variables, `${ENV:NAME}`.
+var envPattern = regexp.MustCompile(`\$\{ENV:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces each reference to an environment variable in value with
+// the variable's value. Referring to a variable that is not set is an error.
+func expandEnv(value string) (string, error) {
+	var err error
+
+	expanded := envPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envPattern.FindStringSubmatch(ref)[1]
+
+		v, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %v is not set", name)
+		}
+
+		return v
+	})
+
+	return expanded, err
+}
+
+// resolveAuth produces a copy of auth with every environment variable expanded.
+func resolveAuth(auth builder.Auth) (builder.Auth, error) {
+	var err error
+	expand := func(value string) string {
+		expanded, e := expandEnv(value)
+		if e != nil && err == nil {
+			err = e
+		}
+
+		return expanded
+	}
+
+	resolved := builder.Auth{Bearer: expand(auth.Bearer)}
+	if auth.Basic != nil {
+		resolved.Basic = &builder.BasicAuth{User: expand(auth.Basic.User), Pass: expand(auth.Basic.Pass)}
+	}
+	if auth.APIKey != nil {
+		resolved.APIKey = &builder.APIKeyAuth{In: auth.APIKey.In, Name: auth.APIKey.Name, Value: expand(auth.APIKey.Value)}
+	}
+	if auth.OAuth2 != nil {
+		resolved.OAuth2 = &builder.OAuth2Auth{
+			TokenURL:     expand(auth.OAuth2.TokenURL),
+			ClientID:     expand(auth.OAuth2.ClientID),
+			ClientSecret: expand(auth.OAuth2.ClientSecret),
+			Scopes:       auth.OAuth2.Scopes,
+		}
+	}
+
+	return resolved, err
+}
+
+// applyAuth authenticates the request as described by auth. OAuth2 tokens are
+// attached by the Runner, which caches them, see Runner.authorize.
+func applyAuth(req *http.Request, auth *builder.Auth) error {
+	if auth == nil {
+		return nil
+	}
+
+	resolved, err := resolveAuth(*auth)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resolved.Basic != nil:
+		req.SetBasicAuth(resolved.Basic.User, resolved.Basic.Pass)
+	case resolved.Bearer != "":
+		req.Header.Set("Authorization", "Bearer "+resolved.Bearer)
+	case resolved.APIKey != nil && resolved.APIKey.In == builder.APIKeyInQuery:
+		query := req.URL.Query()
+		query.Set(resolved.APIKey.Name, resolved.APIKey.Value)
+		req.URL.RawQuery = query.Encode()
+	case resolved.APIKey != nil:
+		req.Header.Set(resolved.APIKey.Name, resolved.APIKey.Value)
+	}
+
+	return nil
+}
+
+// authSecrets produces every secret sent by auth, in each form it may appear
+// within an error, so they can be redacted from reports.
+func authSecrets(auth *builder.Auth) []string {
+	if auth == nil {
+		return nil
+	}
+
+	// Unresolvable secrets are never sent, so ignoring the error is safe.
+	resolved, _ := resolveAuth(*auth)
+
+	var secrets []string
+	if resolved.Basic != nil && resolved.Basic.Pass != "" {
+		credentials := resolved.Basic.User + ":" + resolved.Basic.Pass
+		secrets = append(secrets, resolved.Basic.Pass, base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	if resolved.Bearer != "" {
+		secrets = append(secrets, resolved.Bearer)
+	}
+	if resolved.APIKey != nil && resolved.APIKey.Value != "" {
+		secrets = append(secrets, resolved.APIKey.Value)
+	}
+	if resolved.OAuth2 != nil && resolved.OAuth2.ClientSecret != "" {
+		secrets = append(secrets, resolved.OAuth2.ClientSecret)
+	}
+
+	return secrets
+}
+
+// secrets produces every secret used to authenticate with auth, including the
+// OAuth2 access token cached for it.
+func (r *Runner) secrets(auth *builder.Auth) []string {
+	secrets := authSecrets(auth)
+
+	if auth != nil && auth.OAuth2 != nil {
+		resolved, _ := resolveAuth(*auth)
+		if token := r.cachedToken(*resolved.OAuth2); token != "" {
+			secrets = append(secrets, token)
+		}
+	}
+
+	return secrets
+}
+
+// redact produces err with every secret replaced, or err itself if it does not
+// contain any of them. Secrets are also replaced once encoded for a URL, as
+// they appear in the errors of requests sending them in the query string.
+func redact(err error, secrets []string) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	for _, secret := range secrets {
+		if len(secret) < minRedactedLength {
+			continue
+		}
+
+		msg = strings.Replace(msg, secret, redacted, -1)
+		msg = strings.Replace(msg, url.QueryEscape(secret), redacted, -1)
+	}
+
+	if msg == err.Error() {
+		return err
+	}
+
+	return errors.New(msg)
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("API_CHECK_TEST_TOKEN", "secret")
+	defer os.Unsetenv("API_CHECK_TEST_TOKEN")
+
+	if value, err := expandEnv("Token ${ENV:API_CHECK_TEST_TOKEN}!"); err != nil || value != "Token secret!" {
+		t.Errorf("Expected Token secret! but received %v (%v)", value, err)
+	}
+
+	if value, err := expandEnv("${ENV}"); err != nil || value != "${ENV}" {
+		t.Errorf("Expected text which is not a reference to be unchanged but received %v (%v)", value, err)
+	}
+
+	if _, err := expandEnv("${ENV:API_CHECK_TEST_UNSET}"); err == nil {
+		t.Errorf("Expected an error referring to an unset environment variable")
+	}
+}
+
+func TestApplyAuth(t *testing.T) {
+	tests := []struct {
+		auth     *builder.Auth
+		header   string
+		expected string
+		url      string
+	}{
+		{nil, "Authorization", "", "http://localhost/users?page=1"},
+		{&builder.Auth{}, "Authorization", "", "http://localhost/users?page=1"},
+		{&builder.Auth{Basic: &builder.BasicAuth{User: "jack", Pass: "pass"}}, "Authorization", "Basic amFjazpwYXNz", "http://localhost/users?page=1"},
+		{&builder.Auth{Bearer: "token"}, "Authorization", "Bearer token", "http://localhost/users?page=1"},
+		{&builder.Auth{APIKey: &builder.APIKeyAuth{In: "header", Name: "X-API-Key", Value: "key"}}, "X-API-Key", "key", "http://localhost/users?page=1"},
+		{&builder.Auth{APIKey: &builder.APIKeyAuth{In: "query", Name: "api_key", Value: "key"}}, "X-API-Key", "", "http://localhost/users?api_key=key&page=1"},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost/users?page=1", nil)
+		if err := applyAuth(req, test.auth); err != nil {
+			t.Errorf("Received unexpected error applying %+v: %v", test.auth, err)
+			continue
+		}
+
+		if value := req.Header.Get(test.header); value != test.expected {
+			t.Errorf("Expected %v header %q but received %q", test.header, test.expected, value)
+		}
+
+		if u := req.URL.String(); u != test.url {
+			t.Errorf("Expected url %v but received %v", test.url, u)
+		}
+	}
+}
+
+func TestRunTestRedactsSecrets(t *testing.T) {
+	// Echo the credentials back so they appear in the failure.
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + " " + r.URL.Query().Get("api_key")))
+	})
+	r := Runner{Client: HandlerClient(echo)}
+
+	auths := []*builder.Auth{
+		{Bearer: "s3cr3t-token"},
+		{Basic: &builder.BasicAuth{User: "jack", Pass: "s3cr3t-pass"}},
+		{APIKey: &builder.APIKeyAuth{In: "query", Name: "api_key", Value: "s3cr3t-key"}},
+	}
+
+	for _, auth := range auths {
+		test := builder.APITest{
+			Method:   http.MethodGet,
+			Hostname: "http://localhost",
+			Endpoint: "/",
+			Request:  builder.APIRequest{Auth: auth},
+			Response: builder.APIResponse{StatusCode: http.StatusOK, Body: "expected"},
+		}
+
+		report := r.RunTest(test)
+		if report.Error == nil {
+			t.Fatalf("Expected test to fail")
+		}
+
+		if msg := report.Error.Error(); strings.Contains(msg, "s3cr3t") || !strings.Contains(msg, redacted) {
+			t.Errorf("Expected secrets to be redacted but received: %v", msg)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		err      string
+		secrets  []string
+		expected string
+	}{
+		{"token s3cr3t rejected", []string{"s3cr3t"}, "token [REDACTED] rejected"},
+		{"token endpoint responded with status code 404", []string{"s"}, "token endpoint responded with status code 404"},
+		{`Get "http://localhost/?api_key=s3cr3t+key%26": refused`, []string{"s3cr3t key&"}, `Get "http://localhost/?api_key=[REDACTED]": refused`},
+	}
+
+	for _, test := range tests {
+		if err := redact(errors.New(test.err), test.secrets); err.Error() != test.expected {
+			t.Errorf("Expected %q but received %q", test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/JonathonGore/api-check/config"
+)
+
+// handlerTransport is an http.RoundTripper that serves every request with an
+// http.Handler in-process rather than sending it over the network.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+// RoundTrip converts the outgoing request into the request a server would
+// receive and records the handler's response.
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	serverReq := req.Clone(req.Context())
+	serverReq.RequestURI = req.URL.RequestURI()
+	serverReq.RemoteAddr = "192.0.2.1:1234"
+	if serverReq.Body == nil {
+		serverReq.Body = http.NoBody
+	}
+
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, serverReq)
+
+	resp := recorder.Result()
+	resp.Request = req
+
+	return resp, nil
+}
+
+// HandlerClient creates an http.Client which serves every request using the
+// given handler, regardless of the hostname it is addressed to.
+func HandlerClient(handler http.Handler) *http.Client {
+	return &http.Client{Transport: handlerTransport{handler: handler}}
+}
+
+// serverTransport is an http.RoundTripper that redirects every request to a
+// single server.
+type serverTransport struct {
+	server    *url.URL
+	transport http.RoundTripper
+}
+
+// RoundTrip rewrites the scheme and host of the request to those of the
+// server before sending it.
+func (t serverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.server.Scheme
+	req.URL.Host = t.server.Host
+	req.Host = t.server.Host
+
+	return t.transport.RoundTrip(req)
+}
+
+// ServerClient creates an http.Client which sends every request to the given
+// test server, regardless of the hostname it is addressed to.
+func ServerClient(server *httptest.Server) (*http.Client, error) {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	client := server.Client()
+	return &http.Client{
+		Transport: serverTransport{server: u, transport: client.Transport},
+	}, nil
+}
+
+// NewClient creates the http.Client described by the HTTP config.
+func NewClient(conf config.HTTP) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = conf.DisableKeepAlives
+
+	if conf.Proxy != "" {
+		proxy, err := url.Parse(conf.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("malformed proxy provided: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig, err := newTLSConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	checkRedirect, err := redirectPolicy(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect}, nil
+}
+
+// newTLSConfig creates the TLS config described by the HTTP config.
+func newTLSConfig(conf config.HTTP) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify}
+
+	if conf.CABundle != "" {
+		pem, err := ioutil.ReadFile(conf.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %v", err)
+		}
+
+		// Trust the bundle in addition to the system's authorities.
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %v", conf.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if conf.ClientCert != "" || conf.ClientKey != "" {
+		if conf.ClientCert == "" || conf.ClientKey == "" {
+			return nil, fmt.Errorf("both a client certificate and key must be provided")
+		}
+
+		cert, err := tls.LoadX509KeyPair(conf.ClientCert, conf.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// redirectPolicy produces the CheckRedirect function of the client described
+// by the HTTP config.
+func redirectPolicy(conf config.HTTP) (func(req *http.Request, via []*http.Request) error, error) {
+	switch conf.Redirects {
+	case config.RedirectsNone:
+		return func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}, nil
+	case "", config.RedirectsFollow:
+	default:
+		return nil, fmt.Errorf("redirects must be one of follow or none but found %v", conf.Redirects)
+	}
+
+	if conf.MaxRedirects < 0 {
+		return nil, fmt.Errorf("max-redirects cannot be negative")
+	}
+
+	return followPolicy(conf.MaxRedirects), nil
+}
+
+// followPolicy produces a CheckRedirect func following up to max redirects, or
+// config.DefaultMaxRedirects when max is zero.
+func followPolicy(max int) func(req *http.Request, via []*http.Request) error {
+	if max <= 0 {
+		max = config.DefaultMaxRedirects
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("stopped after %v redirects", max)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,249 @@
+package runner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+	"github.com/JonathonGore/api-check/config"
+)
+
+// echoHandler responds with the method and path of the request it receives.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("X-Path", r.URL.Path)
+	fmt.Fprintf(w, "%v %v", r.Method, string(body))
+})
+
+var echoTest = builder.APITest{
+	Method:   http.MethodPost,
+	Hostname: "http://not-a-real-host.invalid",
+	Endpoint: "/echo",
+	Request:  builder.APIRequest{Body: "hello"},
+	Response: builder.APIResponse{
+		Body:       "POST hello",
+		Headers:    map[string]builder.HeaderMatcher{"X-Path": builder.HeaderValue("/echo")},
+		StatusCode: http.StatusOK,
+	},
+}
+
+func TestHandlerClient(t *testing.T) {
+	r := Runner{Client: HandlerClient(echoHandler)}
+
+	if report := r.RunTest(echoTest); !report.Successful {
+		t.Errorf("expected test against handler to succeed: %v", report.Error)
+	}
+}
+
+func TestServerClient(t *testing.T) {
+	server := httptest.NewServer(echoHandler)
+	defer server.Close()
+
+	client, err := ServerClient(server)
+	if err != nil {
+		t.Fatalf("unable to create server client: %v", err)
+	}
+
+	r := Runner{Client: client}
+	if report := r.RunTest(echoTest); !report.Successful {
+		t.Errorf("expected test against server to succeed: %v", report.Error)
+	}
+}
+
+// writePEM writes a single PEM block to a new file in dir.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("unable to write %v: %v", name, err)
+	}
+
+	return path
+}
+
+// clientCertificate creates a self-signed client certificate and key, writing
+// both to dir.
+func clientCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api-check"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %v", err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unable to marshal key: %v", err)
+	}
+
+	return writePEM(t, dir, "client.pem", "CERTIFICATE", cert), writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", der)
+}
+
+func TestNewClientTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The server only responds successfully to clients presenting a certificate.
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	ca := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	cert, key := clientCertificate(t, dir)
+
+	tests := []struct {
+		conf config.HTTP
+		code int
+	}{
+		{config.HTTP{}, 0}, // The server's certificate is not trusted
+		{config.HTTP{CABundle: ca}, http.StatusUnauthorized},
+		{config.HTTP{InsecureSkipVerify: true}, http.StatusUnauthorized},
+		{config.HTTP{CABundle: ca, ClientCert: cert, ClientKey: key}, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		client, err := NewClient(test.conf)
+		if err != nil {
+			t.Fatalf("Received unexpected error creating client: %v", err)
+		}
+
+		resp, err := client.Get(server.URL)
+		if test.code == 0 {
+			if err == nil {
+				t.Errorf("Expected request to fail with %+v", test.conf)
+				resp.Body.Close()
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Received unexpected error with %+v: %v", test.conf, err)
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.code {
+			t.Errorf("Expected status code %v but received %v with %+v", test.code, resp.StatusCode, test.conf)
+		}
+	}
+}
+
+func TestNewClientRedirects(t *testing.T) {
+	// Each /redirect/n redirects to /redirect/n-1 until /redirect/0.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(r.URL.Path, "/redirect/%d", &n)
+		if n > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/redirect/%v", n-1), http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		conf config.HTTP
+		code int
+	}{
+		{config.HTTP{}, http.StatusOK},
+		{config.HTTP{Redirects: config.RedirectsFollow, MaxRedirects: 3}, http.StatusOK},
+		{config.HTTP{MaxRedirects: 2}, 0},
+		{config.HTTP{Redirects: config.RedirectsNone}, http.StatusFound},
+	}
+
+	for _, test := range tests {
+		client, err := NewClient(test.conf)
+		if err != nil {
+			t.Fatalf("Received unexpected error creating client: %v", err)
+		}
+
+		resp, err := client.Get(server.URL + "/redirect/3")
+		if test.code == 0 {
+			if err == nil {
+				t.Errorf("Expected too many redirects with %+v", test.conf)
+				resp.Body.Close()
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Received unexpected error with %+v: %v", test.conf, err)
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.code {
+			t.Errorf("Expected status code %v but received %v with %+v", test.code, resp.StatusCode, test.conf)
+		}
+	}
+}
+
+func TestNewClientProxy(t *testing.T) {
+	// The proxy receives the absolute URL of every request.
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(config.HTTP{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("Received unexpected error creating client: %v", err)
+	}
+
+	resp, err := client.Get("http://not-a-real-host.invalid/users")
+	if err != nil {
+		t.Fatalf("Received unexpected error sending request through proxy: %v", err)
+	}
+	resp.Body.Close()
+
+	if requested != "http://not-a-real-host.invalid/users" {
+		t.Errorf("Expected request to be sent through the proxy but it received %q", requested)
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	confs := []config.HTTP{
+		{Redirects: "sometimes"},
+		{MaxRedirects: -1},
+		{CABundle: "does-not-exist.pem"},
+		{ClientCert: "client.pem"},
+		{Proxy: "://proxy"},
+	}
+
+	for _, conf := range confs {
+		if _, err := NewClient(conf); err == nil {
+			t.Errorf("Expected an error creating a client from %+v", conf)
+		}
+	}
+}
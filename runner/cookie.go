@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// sameSiteNames maps each SameSite mode to the name used in test definitions.
+var sameSiteNames = map[http.SameSite]string{
+	http.SameSiteDefaultMode: "",
+	http.SameSiteLaxMode:     "Lax",
+	http.SameSiteStrictMode:  "Strict",
+	http.SameSiteNoneMode:    "None",
+}
+
+// findCookie produces the cookie with the given name. When a cookie is set more
+// than once the last one wins, as it would in a browser.
+func findCookie(name string, cookies []*http.Cookie) *http.Cookie {
+	var found *http.Cookie
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			found = cookie
+		}
+	}
+
+	return found
+}
+
+// assertCookie asserts the cookie named name in the response is what the
+// assertion expects. Every attribute that does not match is returned.
+func assertCookie(name string, expected builder.CookieAssertion, cookies []*http.Cookie) []error {
+	cookie := findCookie(name, cookies)
+
+	if expected.Absent {
+		if cookie != nil {
+			return []error{fmt.Errorf("Unexpected %v cookie\n\nExpected:\nno cookie\n\nActual:\n%v\n\n", name, cookie.Value)}
+		}
+
+		return nil
+	}
+
+	if cookie == nil {
+		return []error{fmt.Errorf("Missing %v cookie", name)}
+	}
+
+	var failures []error
+	mismatch := func(attribute string, expected, actual interface{}) {
+		failures = append(failures, fmt.Errorf("Mismatching %v of %v cookie\n\nExpected:\n%v\n\nActual:\n%v\n\n", attribute, name, expected, actual))
+	}
+
+	if expected.Value != nil && *expected.Value != cookie.Value {
+		mismatch("value", *expected.Value, cookie.Value)
+	}
+
+	if expected.Pattern != "" {
+		if re, err := regexp.Compile(expected.Pattern); err != nil {
+			failures = append(failures, fmt.Errorf("invalid pattern for %v cookie: %v", name, err))
+		} else if !re.MatchString(cookie.Value) {
+			mismatch("value", "match for "+expected.Pattern, cookie.Value)
+		}
+	}
+
+	if expected.HTTPOnly != nil && *expected.HTTPOnly != cookie.HttpOnly {
+		mismatch("HttpOnly", *expected.HTTPOnly, cookie.HttpOnly)
+	}
+
+	if expected.Secure != nil && *expected.Secure != cookie.Secure {
+		mismatch("Secure", *expected.Secure, cookie.Secure)
+	}
+
+	if sameSite := sameSiteNames[cookie.SameSite]; expected.SameSite != "" && !strings.EqualFold(expected.SameSite, sameSite) {
+		mismatch("SameSite", expected.SameSite, sameSite)
+	}
+
+	if expected.Path != "" && expected.Path != cookie.Path {
+		mismatch("Path", expected.Path, cookie.Path)
+	}
+
+	if expected.Domain != "" && expected.Domain != cookie.Domain {
+		mismatch("Domain", expected.Domain, cookie.Domain)
+	}
+
+	// net/http uses 0 for a missing Max-Age and a negative value for Max-Age=0.
+	if expected.MaxAge != nil {
+		switch {
+		case cookie.MaxAge == 0:
+			mismatch("Max-Age", *expected.MaxAge, "none")
+		case cookie.MaxAge < 0 && *expected.MaxAge != 0:
+			mismatch("Max-Age", *expected.MaxAge, 0)
+		case cookie.MaxAge > 0 && *expected.MaxAge != cookie.MaxAge:
+			mismatch("Max-Age", *expected.MaxAge, cookie.MaxAge)
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+func TestAssertCookie(t *testing.T) {
+	header := make(http.Header)
+	header.Add("Set-Cookie", "session=abc123; Path=/; Domain=example.com; Max-Age=3600; HttpOnly; Secure; SameSite=Strict")
+	header.Add("Set-Cookie", "theme=dark")
+	header.Add("Set-Cookie", "old=; Max-Age=0")
+
+	resp := http.Response{Header: header}
+	cookies := resp.Cookies()
+
+	yes, no := true, false
+	value, other := "abc123", "xyz"
+	hour, zero := 3600, 0
+
+	tests := []struct {
+		name     string
+		expected builder.CookieAssertion
+		failures int
+	}{
+		{"session", builder.CookieAssertion{Value: &value, HTTPOnly: &yes, Secure: &yes, SameSite: "strict", Path: "/", Domain: "example.com", MaxAge: &hour}, 0},
+		{"session", builder.CookieAssertion{Pattern: "^[a-z0-9]+$"}, 0},
+		{"session", builder.CookieAssertion{Value: &other, HTTPOnly: &no, SameSite: "Lax"}, 3},
+		{"theme", builder.CookieAssertion{HTTPOnly: &yes, Secure: &yes, MaxAge: &hour}, 3},
+		{"old", builder.CookieAssertion{MaxAge: &zero}, 0},
+		{"old", builder.CookieAssertion{MaxAge: &hour}, 1},
+		{"missing", builder.CookieAssertion{Absent: true}, 0},
+		{"missing", builder.CookieAssertion{}, 1},
+		{"theme", builder.CookieAssertion{Absent: true}, 1},
+	}
+
+	for _, test := range tests {
+		if failures := assertCookie(test.name, test.expected, cookies); len(failures) != test.failures {
+			t.Errorf("Expected %v failures asserting %v cookie with %+v but received %v: %v", test.failures, test.name, test.expected, len(failures), failures)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// assertHeader asserts the values of the header named key in the response are
+// what the matcher expects, producing an error describing the mismatch if not.
+func assertHeader(key string, matcher builder.HeaderMatcher, header http.Header) error {
+	values := header.Values(key)
+
+	if matcher.Absent {
+		if len(values) > 0 {
+			return fmt.Errorf("Unexpected %v header\n\nExpected:\nno header\n\nActual:\n%v\n\n", key, strings.Join(values, "\n"))
+		}
+
+		return nil
+	}
+
+	if matcher.Value != nil && *matcher.Value != header.Get(key) {
+		return fmt.Errorf("Mismatching %v header\n\nExpected:\n%v\n\nActual:\n%v\n\n", key, *matcher.Value, header.Get(key))
+	}
+
+	if matcher.Values != nil && !reflect.DeepEqual(matcher.Values, append([]string{}, values...)) {
+		return fmt.Errorf("Mismatching %v header values\n\nExpected:\n%v\n\nActual:\n%v\n\n", key, strings.Join(matcher.Values, "\n"), strings.Join(values, "\n"))
+	}
+
+	if matcher.Contains != "" && !anyValue(values, func(v string) bool { return strings.Contains(v, matcher.Contains) }) {
+		return fmt.Errorf("Mismatching %v header\n\nExpected to contain:\n%v\n\nActual:\n%v\n\n", key, matcher.Contains, strings.Join(values, "\n"))
+	}
+
+	if matcher.Regex != "" {
+		re, err := regexp.Compile(matcher.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression for %v header: %v", key, err)
+		}
+
+		if !anyValue(values, re.MatchString) {
+			return fmt.Errorf("Mismatching %v header\n\nExpected to match:\n%v\n\nActual:\n%v\n\n", key, matcher.Regex, strings.Join(values, "\n"))
+		}
+	}
+
+	return nil
+}
+
+// anyValue determines if any of the header values satisfy match.
+func anyValue(values []string, match func(value string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+
+	return false
+}
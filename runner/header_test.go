@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+func TestAssertHeader(t *testing.T) {
+	header := make(http.Header)
+	header.Set("Cache-Control", "private, no-store")
+	header.Add("Set-Cookie", "a=1")
+	header.Add("Set-Cookie", "b=2")
+
+	tests := []struct {
+		key     string
+		matcher builder.HeaderMatcher
+		succeed bool
+	}{
+		{"Cache-Control", builder.HeaderValue("private, no-store"), true},
+		{"Cache-Control", builder.HeaderValue("no-store"), false},
+		{"Cache-Control", builder.HeaderMatcher{Contains: "no-store"}, true},
+		{"Cache-Control", builder.HeaderMatcher{Contains: "no-cache"}, false},
+		{"Cache-Control", builder.HeaderMatcher{Regex: "^private"}, true},
+		{"Cache-Control", builder.HeaderMatcher{Regex: "^public"}, false},
+		{"Set-Cookie", builder.HeaderMatcher{Values: []string{"a=1", "b=2"}}, true},
+		{"Set-Cookie", builder.HeaderMatcher{Values: []string{"a=1"}}, false},
+		{"Set-Cookie", builder.HeaderMatcher{Regex: "^b="}, true}, // Any value may match
+		{"X-Powered-By", builder.HeaderMatcher{Absent: true}, true},
+		{"Cache-Control", builder.HeaderMatcher{Absent: true}, false},
+		{"X-Powered-By", builder.HeaderMatcher{Values: []string{}}, true},
+		{"X-Powered-By", builder.HeaderValue(""), true}, // A missing header has an empty value
+	}
+
+	for _, test := range tests {
+		if err := assertHeader(test.key, test.matcher, header); (err == nil) != test.succeed {
+			t.Errorf("Received unexpected result %v asserting %v header with %+v", err, test.key, test.matcher)
+		}
+	}
+}
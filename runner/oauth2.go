@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// tokenExpiryLeeway is how long before it expires a token is refreshed, so it
+// does not expire while a request is being sent.
+const tokenExpiryLeeway = 10 * time.Second
+
+// oauth2Token is an access token acquired from a token endpoint.
+type oauth2Token struct {
+	accessToken string
+
+	// expiry is when the token should be refreshed, zero if it never expires.
+	expiry time.Time
+}
+
+// valid determines if the token can still be used.
+func (t oauth2Token) valid() bool {
+	return t.accessToken != "" && (t.expiry.IsZero() || time.Now().Before(t.expiry))
+}
+
+// tokenResponse is the successful response of a token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenKey identifies the tokens of a single client, tokens are shared between
+// every test using the same client and scopes.
+func tokenKey(auth builder.OAuth2Auth) string {
+	return strings.Join([]string{auth.TokenURL, auth.ClientID, strings.Join(auth.Scopes, " ")}, "\x00")
+}
+
+// fetchToken requests a new access token using the client credentials grant.
+func fetchToken(client *http.Client, auth builder.OAuth2Auth) (oauth2Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return oauth2Token{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return oauth2Token{}, fmt.Errorf("token endpoint responded with status code %v: %v", resp.StatusCode, string(body))
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return oauth2Token{}, fmt.Errorf("unable to decode token response: %v", err)
+	} else if token.AccessToken == "" {
+		return oauth2Token{}, fmt.Errorf("token endpoint did not respond with an access_token")
+	}
+
+	result := oauth2Token{accessToken: token.AccessToken}
+	if token.ExpiresIn > 0 {
+		result.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryLeeway)
+	}
+
+	return result, nil
+}
+
+// token produces an access token for auth, reusing the cached token unless it
+// has expired or refresh is true. The boolean result reports whether the token
+// was reused from the cache rather than just acquired.
+func (r *Runner) token(client *http.Client, auth builder.OAuth2Auth, refresh bool) (string, bool, error) {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+
+	if r.tokens == nil {
+		r.tokens = make(map[string]oauth2Token)
+	}
+
+	key := tokenKey(auth)
+	if token, ok := r.tokens[key]; ok && token.valid() && !refresh {
+		return token.accessToken, true, nil
+	}
+
+	token, err := fetchToken(client, auth)
+	if err != nil {
+		delete(r.tokens, key)
+		return "", false, fmt.Errorf("unable to acquire OAuth2 token: %v", err)
+	}
+	r.tokens[key] = token
+
+	return token.accessToken, false, nil
+}
+
+// cachedToken produces the cached access token for auth, if any.
+func (r *Runner) cachedToken(auth builder.OAuth2Auth) string {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+
+	return r.tokens[tokenKey(auth)].accessToken
+}
+
+// authorize attaches an OAuth2 access token to the request if auth uses
+// OAuth2. When refresh is true a new token is always acquired. The boolean
+// result reports whether a cached token was reused, only then may a rejected
+// request be caused by a token revoked since it was acquired.
+func (r *Runner) authorize(client *http.Client, req *http.Request, auth *builder.Auth, refresh bool) (bool, error) {
+	if auth == nil || auth.OAuth2 == nil {
+		return false, nil
+	}
+
+	resolved, err := resolveAuth(*auth)
+	if err != nil {
+		return false, fmt.Errorf("unable to authenticate request: %v", err)
+	}
+
+	token, reused, err := r.token(client, *resolved.OAuth2, refresh)
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return reused, nil
+}
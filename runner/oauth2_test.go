@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// tokenServer is a stand-in OAuth2 token endpoint and API accepting only the
+// most recently issued token.
+type tokenServer struct {
+	mu        sync.Mutex
+	issued    int
+	expiresIn int
+	current   string
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/token" {
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.issued++
+		s.current = fmt.Sprintf("token-%v", s.issued)
+		fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer", "expires_in": %v}`, s.current, s.expiresIn)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.current {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Echo the token so it appears in failures.
+	w.Write([]byte(s.current))
+}
+
+// revoke invalidates the current token without the client knowing.
+func (s *tokenServer) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = "revoked"
+}
+
+func oauth2Test(server *httptest.Server, body string) builder.APITest {
+	return builder.APITest{
+		Method:   http.MethodGet,
+		Hostname: server.URL,
+		Endpoint: "/users",
+		Request: builder.APIRequest{Auth: &builder.Auth{OAuth2: &builder.OAuth2Auth{
+			TokenURL:     server.URL + "/token",
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Scopes:       []string{"read", "write"},
+		}}},
+		Response: builder.APIResponse{StatusCode: http.StatusOK, Body: body},
+	}
+}
+
+func TestRunTestOAuth2(t *testing.T) {
+	ts := &tokenServer{expiresIn: 3600}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	r := Runner{}
+
+	// The token is acquired once and reused.
+	for i := 0; i < 2; i++ {
+		if report := r.RunTest(oauth2Test(server, "")); !report.Successful {
+			t.Fatalf("Expected test to succeed but it failed with: %v", report.Error)
+		}
+	}
+	if ts.issued != 1 {
+		t.Errorf("Expected a single token to be issued but received %v", ts.issued)
+	}
+
+	// A revoked token is refreshed after the request is rejected.
+	ts.revoke()
+	if report := r.RunTest(oauth2Test(server, "")); !report.Successful {
+		t.Errorf("Expected test to succeed with a new token but it failed with: %v", report.Error)
+	}
+	if ts.issued != 2 {
+		t.Errorf("Expected a new token to be issued but received %v", ts.issued)
+	}
+
+	// The token is redacted from failures.
+	report := r.RunTest(oauth2Test(server, "expected"))
+	if report.Error == nil || strings.Contains(report.Error.Error(), "token-2") {
+		t.Errorf("Expected token to be redacted but received: %v", report.Error)
+	}
+}
+
+func TestRunTestOAuth2Expiry(t *testing.T) {
+	// Tokens expiring within the leeway are refreshed before every request.
+	ts := &tokenServer{expiresIn: 1}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	r := Runner{}
+	for i := 0; i < 2; i++ {
+		if report := r.RunTest(oauth2Test(server, "")); !report.Successful {
+			t.Fatalf("Expected test to succeed but it failed with: %v", report.Error)
+		}
+	}
+
+	if ts.issued != 2 {
+		t.Errorf("Expected expired token to be refreshed but %v tokens were issued", ts.issued)
+	}
+}
+
+func TestRunTestOAuth2TokenError(t *testing.T) {
+	server := httptest.NewServer(&tokenServer{})
+	defer server.Close()
+
+	test := oauth2Test(server, "")
+	test.Request.Auth.OAuth2.ClientSecret = "wrong"
+
+	r := Runner{}
+	report := r.RunTest(test)
+	if report.Error == nil || !strings.Contains(report.Error.Error(), "unable to acquire OAuth2 token") {
+		t.Errorf("Expected token error but received: %v", report.Error)
+	}
+}
+
+func TestRunTestOAuth2ExpectedUnauthorized(t *testing.T) {
+	ts := &tokenServer{expiresIn: 3600}
+
+	// The API denies every request, which the test expects.
+	requests := 0
+	mux := http.NewServeMux()
+	mux.Handle("/token", ts)
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	test := oauth2Test(server, "")
+	test.Method = http.MethodPost
+	test.Response.StatusCode = http.StatusUnauthorized
+
+	r := Runner{}
+
+	// A freshly acquired token is never refreshed, so the POST is sent once.
+	if report := r.RunTest(test); !report.Successful {
+		t.Fatalf("Expected test to succeed but it failed with: %v", report.Error)
+	}
+	if requests != 1 || ts.issued != 1 {
+		t.Errorf("Expected 1 request and 1 token but received %v requests and %v tokens", requests, ts.issued)
+	}
+
+	// A cached token is refreshed once in case it was revoked.
+	if report := r.RunTest(test); !report.Successful {
+		t.Fatalf("Expected test to succeed but it failed with: %v", report.Error)
+	}
+	if requests != 3 || ts.issued != 2 {
+		t.Errorf("Expected 3 requests and 2 tokens but received %v requests and %v tokens", requests, ts.issued)
+	}
+}
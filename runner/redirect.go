@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// redirectClient produces a copy of client following redirects as the test
+// describes, or client itself if the test does not override it. A test which
+// follows redirects follows up to max of them.
+func redirectClient(client *http.Client, test builder.APITest, max int) *http.Client {
+	if test.FollowRedirects == nil {
+		return client
+	}
+
+	c := *client
+	if *test.FollowRedirects {
+		c.CheckRedirect = followPolicy(max)
+	} else {
+		c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return &c
+}
+
+// redirectChain produces every redirect response followed before resp was
+// received, in the order they were received.
+func redirectChain(resp *http.Response) []*http.Response {
+	var chain []*http.Response
+
+	// Each request made to follow a redirect refers to the redirect response.
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append([]*http.Response{req.Response}, chain...)
+	}
+
+	return chain
+}
+
+// assertRedirects asserts the redirects followed before resp was received are
+// those expected, producing every mismatch.
+func assertRedirects(resp *http.Response, expected []builder.RedirectHop) []error {
+	if expected == nil {
+		return nil
+	}
+
+	chain := redirectChain(resp)
+	if len(chain) != len(expected) {
+		return []error{fmt.Errorf("Unexpected number of redirects\n\nExpected:\n%v\n\nActual:\n%v\n\n", len(expected), len(chain))}
+	}
+
+	var failures []error
+	for i, hop := range expected {
+		actual := chain[i]
+
+		if hop.StatusCode != actual.StatusCode {
+			failures = append(failures, fmt.Errorf("Unexpected status code for redirect #%v\n\nExpected:\n%v\n\nActual:\n%v\n\n", i+1, hop.StatusCode, actual.StatusCode))
+		}
+
+		if location := actual.Header.Get("Location"); hop.Location != "" && hop.Location != location {
+			failures = append(failures, fmt.Errorf("Mismatching location for redirect #%v\n\nExpected:\n%v\n\nActual:\n%v\n\n", i+1, hop.Location, location))
+		}
+	}
+
+	return failures
+}
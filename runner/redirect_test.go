@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+	"github.com/JonathonGore/api-check/config"
+)
+
+// redirectHandler redirects /login to /home which permanently moved to
+// /dashboard.
+var redirectHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/login":
+		http.Redirect(w, r, "/home", http.StatusFound)
+	case "/home":
+		http.Redirect(w, r, "/dashboard", http.StatusMovedPermanently)
+	}
+})
+
+func TestRunTestRedirects(t *testing.T) {
+	r := Runner{Client: HandlerClient(redirectHandler)}
+	follow, stay := true, false
+
+	tests := []struct {
+		follow   *bool
+		response builder.APIResponse
+		succeed  bool
+	}{
+		{nil, builder.APIResponse{StatusCode: http.StatusOK}, true},
+		{&follow, builder.APIResponse{StatusCode: http.StatusOK, Redirects: []builder.RedirectHop{
+			{StatusCode: http.StatusFound, Location: "/home"},
+			{StatusCode: http.StatusMovedPermanently, Location: "/dashboard"},
+		}}, true},
+		{nil, builder.APIResponse{StatusCode: http.StatusOK, Redirects: []builder.RedirectHop{
+			{StatusCode: http.StatusFound},
+			{StatusCode: http.StatusFound},
+		}}, false},
+		{nil, builder.APIResponse{StatusCode: http.StatusOK, Redirects: []builder.RedirectHop{
+			{StatusCode: http.StatusFound, Location: "/home"},
+		}}, false},
+		{&stay, builder.APIResponse{StatusCode: http.StatusFound, Headers: map[string]builder.HeaderMatcher{
+			"Location": builder.HeaderValue("/home"),
+		}}, true},
+	}
+
+	for _, test := range tests {
+		report := r.RunTest(builder.APITest{
+			Method:          http.MethodGet,
+			Hostname:        "http://localhost",
+			Endpoint:        "/login",
+			FollowRedirects: test.follow,
+			Response:        test.response,
+		})
+
+		if report.Successful != test.succeed {
+			t.Errorf("Received unexpected result %v for %+v", report.Error, test.response)
+		}
+	}
+}
+
+func TestRedirectClient(t *testing.T) {
+	client := &http.Client{}
+	if redirectClient(client, builder.APITest{}, 0) != client {
+		t.Errorf("Expected client to be unchanged when the test does not override redirects")
+	}
+}
+
+func TestRunTestFollowRedirectsMax(t *testing.T) {
+	// Each /redirect/n redirects to /redirect/n-1 until /redirect/0.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		if _, err := fmt.Sscanf(r.URL.Path, "/redirect/%d", &n); err == nil && n > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+		}
+	})
+
+	client, err := NewClient(config.HTTP{Redirects: config.RedirectsNone})
+	if err != nil {
+		t.Fatalf("Received unexpected error creating client: %v", err)
+	}
+	client.Transport = HandlerClient(handler).Transport
+
+	follow := true
+	tests := []struct {
+		max      int
+		endpoint string
+		succeed  bool
+	}{
+		{2, "/redirect/2", true},
+		{2, "/redirect/4", false},
+		{0, "/redirect/4", true},
+	}
+
+	for _, test := range tests {
+		r := Runner{Client: client, MaxRedirects: test.max}
+		report := r.RunTest(builder.APITest{
+			Method:          http.MethodGet,
+			Hostname:        "http://localhost",
+			Endpoint:        test.endpoint,
+			FollowRedirects: &follow,
+			Response:        builder.APIResponse{StatusCode: http.StatusOK},
+		})
+
+		if report.Successful != test.succeed {
+			t.Errorf("Received unexpected result %v following %v with at most %v redirects", report.Error, test.endpoint, test.max)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+const (
+	// DefaultRetryAttempts is the number of attempts made when neither the
+	// attempts nor the timeout of a retry are given.
+	DefaultRetryAttempts = 3
+
+	// DefaultRetryInterval is how long to wait between attempts when the
+	// interval of a retry is not given.
+	DefaultRetryInterval = time.Second
+)
+
+// shouldRetry determines if the attempt described by report, which received a
+// response with the given status code, should be attempted again.
+func shouldRetry(policy builder.Retry, eventually bool, report RunReport, code int) bool {
+	if report.Successful {
+		return false
+	} else if eventually {
+		return true
+	}
+
+	if code == 0 {
+		return policy.OnError
+	}
+
+	for _, status := range policy.OnStatus {
+		if status == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retry attempts the test until it should no longer be retried, or either the
+// attempts or timeout of its retry policy are reached. Tests with an eventually
+// policy are attempted until they succeed.
+func (r *Runner) retry(client *http.Client, test builder.APITest) RunReport {
+	policy, eventually := test.Retry, false
+	if test.Eventually != nil {
+		policy, eventually = test.Eventually, true
+	}
+
+	attempts := policy.Attempts
+	if attempts == 0 && policy.Timeout == 0 {
+		attempts = DefaultRetryAttempts
+	}
+
+	interval := time.Duration(policy.Interval)
+	if interval == 0 {
+		interval = DefaultRetryInterval
+	}
+
+	backoff := policy.Backoff
+	if backoff == 0 {
+		backoff = 1
+	}
+
+	deadline := time.Now().Add(time.Duration(policy.Timeout))
+
+	for n := 1; ; n++ {
+		report, code := r.attempt(client, test)
+		report.Attempts = n
+
+		if !shouldRetry(*policy, eventually, report, code) {
+			return report
+		}
+
+		if attempts > 0 && n >= attempts {
+			return report
+		}
+
+		// Give up rather than wait past the timeout.
+		if policy.Timeout > 0 && time.Now().Add(interval).After(deadline) {
+			return report
+		}
+
+		time.Sleep(interval)
+		interval = time.Duration(float64(interval) * backoff)
+	}
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// countingHandler responds with each status code in turn, then with the last
+// one forever.
+type countingHandler struct {
+	mu       sync.Mutex
+	requests int
+	codes    []int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	code := h.codes[len(h.codes)-1]
+	if h.requests < len(h.codes) {
+		code = h.codes[h.requests]
+	}
+	h.requests++
+
+	w.WriteHeader(code)
+}
+
+func retryTest(retry, eventually *builder.Retry) builder.APITest {
+	return builder.APITest{
+		Method:     http.MethodGet,
+		Hostname:   "http://localhost",
+		Endpoint:   "/jobs/1",
+		Retry:      retry,
+		Eventually: eventually,
+		Response:   builder.APIResponse{StatusCode: http.StatusOK},
+	}
+}
+
+func TestRunTestRetry(t *testing.T) {
+	interval := builder.Duration(time.Millisecond)
+
+	tests := []struct {
+		codes      []int
+		retry      *builder.Retry
+		eventually *builder.Retry
+		attempts   int
+		succeed    bool
+	}{
+		{[]int{503, 200}, nil, nil, 1, false},
+		{[]int{503, 503, 200}, &builder.Retry{Interval: interval, OnStatus: []int{503}}, nil, 3, true},
+		{[]int{503}, &builder.Retry{Interval: interval, OnStatus: []int{503}}, nil, DefaultRetryAttempts, false},
+		{[]int{503}, &builder.Retry{Attempts: 5, Interval: interval, OnStatus: []int{503}}, nil, 5, false},
+		{[]int{404, 200}, &builder.Retry{Interval: interval, OnStatus: []int{503}}, nil, 1, false}, // Other codes are not retried
+		{[]int{202, 202, 202, 200}, nil, &builder.Retry{Attempts: 10, Interval: interval}, 4, true},
+		{[]int{200}, nil, &builder.Retry{Interval: interval}, 1, true},
+	}
+
+	for _, test := range tests {
+		handler := &countingHandler{codes: test.codes}
+		r := Runner{Client: HandlerClient(handler)}
+
+		report := r.RunTest(retryTest(test.retry, test.eventually))
+		if report.Successful != test.succeed || report.Attempts != test.attempts || handler.requests != test.attempts {
+			t.Errorf("Expected %v attempts and success %v with %v but received %v attempts, %v requests: %v", test.attempts, test.succeed, test.codes, report.Attempts, handler.requests, report.Error)
+		}
+	}
+}
+
+func TestRunTestRetryTimeout(t *testing.T) {
+	handler := &countingHandler{codes: []int{202}}
+	r := Runner{Client: HandlerClient(handler)}
+
+	eventually := &builder.Retry{
+		Interval: builder.Duration(10 * time.Millisecond),
+		Timeout:  builder.Duration(100 * time.Millisecond),
+		Backoff:  2,
+	}
+
+	// Attempts are made after 0, 10, 30 and 70ms, the next would be after the timeout.
+	start := time.Now()
+	report := r.RunTest(retryTest(nil, eventually))
+	if report.Successful || report.Attempts != 4 {
+		t.Errorf("Expected 4 failed attempts but received %v: %v", report.Attempts, report.Error)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected attempts to stop at the timeout but they took %v", elapsed)
+	}
+}
+
+func TestRunTestRetryOnError(t *testing.T) {
+	// Nothing listens on the port so every request fails.
+	test := retryTest(&builder.Retry{Attempts: 2, Interval: builder.Duration(time.Millisecond), OnError: true}, nil)
+	test.Hostname = "http://127.0.0.1:1"
+
+	r := Runner{}
+	if report := r.RunTest(test); report.Successful || report.Attempts != 2 {
+		t.Errorf("Expected 2 failed attempts but received %v: %v", report.Attempts, report.Error)
+	}
+}
+
+func TestRunTestReruns(t *testing.T) {
+	tests := []struct {
+		codes  []int
+		reruns int
+		status Status
+		ran    int
+	}{
+		{[]int{200}, 2, StatusPassed, 1},
+		{[]int{500, 200}, 0, StatusFailed, 1},
+		{[]int{500, 500, 200}, 2, StatusFlaky, 3},
+		{[]int{500}, 2, StatusFailed, 3},
+	}
+
+	for _, test := range tests {
+		handler := &countingHandler{codes: test.codes}
+		r := Runner{Client: HandlerClient(handler), Reruns: test.reruns}
+
+		report := r.RunTest(retryTest(nil, nil))
+		if report.Status != test.status || report.Reruns != test.ran-1 || handler.requests != test.ran {
+			t.Errorf("Expected %v after %v runs with %v but received %v after %v: %v", test.status, test.ran, test.codes, report.Status, handler.requests, report.Error)
+		}
+
+		if report.Successful != (test.status != StatusFailed) {
+			t.Errorf("Expected flaky and passed tests to be successful but received %v for %v", report.Successful, report.Status)
+		}
+	}
+}
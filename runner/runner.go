@@ -7,17 +7,50 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/JonathonGore/api-check/builder"
 )
 
+// Status describes the outcome of a single test.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+
+	// StatusFlaky is a test which failed but passed when it was run again.
+	StatusFlaky Status = "flaky"
+)
+
+// notOnlyReason is the reason given for skipping tests when another test is
+// marked as only.
+const notOnlyReason = "another test is marked as only"
+
 // RunReport describes the result of a single test.
 type RunReport struct {
-	Test           builder.APITest
+	Test   builder.APITest
+	Status Status
+
+	// Successful is only true when the test ran and passed, including flaky
+	// tests which passed when run again.
 	Successful     bool
 	Error          error
 	FailureMessage string
+
+	// Failures holds every assertion that failed for the test. When non-empty
+	// Error is set to the same list.
+	Failures AssertionErrors
+
+	// Attempts is how many times the test was attempted, more than once only
+	// when it is retried. The report describes the final attempt.
+	Attempts int
+
+	// Reruns is how many times the test was run again after failing.
+	Reruns int
 }
 
 // buildQueryString Consumes a map of string => string representing query params
@@ -196,17 +229,34 @@ func assertJSON(actual interface{}, expected interface{}) bool {
 	return reflect.DeepEqual(actual, expected)
 }
 
+// AssertionErrors is the list of every assertion that did not hold for a
+// single test.
+type AssertionErrors []error
+
+// Error joins the message of each failed assertion.
+func (e AssertionErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
 // AssertResponse consume the http response from the server and the struct containing the
-// expected results and compares the two and ensures they are equal
-func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, error) {
+// expected results and compares the two. Every assertion is evaluated and each one that
+// fails is returned, an empty result means the response is what we expected.
+func assertResponse(resp *http.Response, expected builder.APIResponse) AssertionErrors {
+	var failures AssertionErrors
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return false, err
+		return append(failures, err)
 	}
 
 	// Ensure status code is what is expected
 	if expected.StatusCode != resp.StatusCode {
-		return false, fmt.Errorf("Unexpected status code received\n\nExpected:\n%v\n\nActual:\n%v\n\n", expected.StatusCode, resp.StatusCode)
+		failures = append(failures, fmt.Errorf("Unexpected status code received\n\nExpected:\n%v\n\nActual:\n%v\n\n", expected.StatusCode, resp.StatusCode))
 	}
 
 	// NOTE: There are basically 3 ways for us to compare request body content.
@@ -217,12 +267,31 @@ func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, er
 	// 3) Third is by only looking at the structure of the returned JSON.
 	//
 	// It is important that we only perform one of these three actions as it
-	// could result in weird behaviour if we do otherwise.
+	// could result in weird behaviour if we do otherwise. The parser rejects
+	// tests giving more than one of them.
+
+	// An empty expected body means the body is not checked, so an empty body
+	// is asserted separately.
+	if expected.EmptyBody && len(body) > 0 {
+		failures = append(failures, fmt.Errorf("Expected an empty body\n\nActual:\n%v\n\n", string(body)))
+	}
+
+	// Responses without a Content-Length header, such as chunked responses, are
+	// compared using the length of the body received.
+	if expected.ContentLength != nil {
+		length := resp.ContentLength
+		if length < 0 {
+			length = int64(len(body))
+		}
+
+		if *expected.ContentLength != length {
+			failures = append(failures, fmt.Errorf("Unexpected content length received\n\nExpected:\n%v\n\nActual:\n%v\n\n", *expected.ContentLength, length))
+		}
+	}
 
 	// Ensure the bodies are the same only if the expected body is non-empty
-	// NOTE: Right now we have no way of asserting the response body is empty
 	if expected.Body != "" && expected.Body != string(body) {
-		return false, fmt.Errorf("Mismatching bodies\n\nExpected:\n%v\n\nActual:\n%v\n\n", expected.Body, string(body))
+		failures = append(failures, fmt.Errorf("Mismatching bodies\n\nExpected:\n%v\n\nActual:\n%v\n\n", expected.Body, string(body)))
 	}
 
 	// Check the structure of the response if TypeOf is present in API Test
@@ -230,13 +299,10 @@ func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, er
 		// TypeOf will be one of interface{}, map[string]interface{} or string
 		var actual interface{}
 
-		err = json.Unmarshal(body, &actual)
-		if err != nil {
-			return false, fmt.Errorf("received JSON in unexpected format %v", err)
-		}
-
-		if !assertJSONStructure(actual, *expected.TypeOf) {
-			return false, fmt.Errorf("mismatching JSON structure")
+		if err := json.Unmarshal(body, &actual); err != nil {
+			failures = append(failures, fmt.Errorf("received JSON in unexpected format %v", err))
+		} else if !assertJSONStructure(actual, *expected.TypeOf) {
+			failures = append(failures, fmt.Errorf("mismatching JSON structure"))
 		}
 	}
 
@@ -244,24 +310,41 @@ func assertResponse(resp *http.Response, expected builder.APIResponse) (bool, er
 	if expected.TypeOf == nil && expected.Body == "" && expected.JSON != nil {
 		var actual interface{}
 
-		err = json.Unmarshal(body, &actual)
-		if err != nil {
-			return false, fmt.Errorf("Received unexpected error when unmarshaling JSON %v", err)
+		if err := json.Unmarshal(body, &actual); err != nil {
+			failures = append(failures, fmt.Errorf("Received unexpected error when unmarshaling JSON %v", err))
+		} else if !assertJSON(actual, expected.JSON) {
+			failures = append(failures, fmt.Errorf("Mismatching JSON"))
 		}
+	}
 
-		if !assertJSON(actual, expected.JSON) {
-			return false, fmt.Errorf("Mismatching JSON")
-		}
+	failures = append(failures, assertRedirects(resp, expected.Redirects)...)
+
+	// Ensure headers are what we expect, in a stable order so failures are
+	// always reported the same way.
+	keys := make([]string, 0, len(expected.Headers))
+	for key := range expected.Headers {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	// Ensure headers are what we expect
-	for key, value := range expected.Headers {
-		if value != resp.Header.Get(key) {
-			return false, fmt.Errorf("Mismatching %v header\n\nExpected:\n%v\n\nActual:\n%v\n\n", key, value, resp.Header.Get(key))
+	for _, key := range keys {
+		if err := assertHeader(key, expected.Headers[key], resp.Header); err != nil {
+			failures = append(failures, err)
 		}
 	}
 
-	return true, nil
+	names := make([]string, 0, len(expected.Cookies))
+	for name := range expected.Cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cookies := resp.Cookies()
+	for _, name := range names {
+		failures = append(failures, assertCookie(name, expected.Cookies[name], cookies)...)
+	}
+
+	return failures
 }
 
 // BuildRequest consumes an api test object and produces the corresponding http request
@@ -308,45 +391,236 @@ func buildRequest(test builder.APITest) (*http.Request, error) {
 		req.Header.Set("Cookie", cookieHeader)
 	}
 
+	if err := applyAuth(req, test.Request.Auth); err != nil {
+		return nil, fmt.Errorf("unable to authenticate request: %v", err)
+	}
+
 	return req, nil
 }
 
+// Runner runs a set of APITests.
+type Runner struct {
+	// Client is used to send the request of every test. When nil
+	// http.DefaultClient is used, see NewClient to configure a client.
+	Client *http.Client
+
+	// TokenClient, when non-nil, is used to request OAuth2 tokens instead of
+	// Client. It allows tokens to be requested from a real token endpoint while
+	// Client serves requests in-process.
+	TokenClient *http.Client
+
+	// Progress, when non-nil, is called after each test is run with its report
+	// along with the number of tests completed so far and the total to run.
+	Progress func(report RunReport, done, total int)
+
+	// Signer, when non-nil, signs every request once it is built.
+	Signer Signer
+
+	// Reruns is how many times a failed test is run again. A test which passes
+	// when run again is reported as flaky.
+	Reruns int
+
+	// MaxRedirects is how many redirects a test setting followRedirects follows
+	// before failing. When zero config.DefaultMaxRedirects are followed.
+	MaxRedirects int
+
+	// mu guards sessions, which may be used by tests running in parallel.
+	mu       sync.Mutex
+	sessions map[string]http.CookieJar
+
+	// tokenMu guards tokens, the cached OAuth2 access tokens.
+	tokenMu sync.Mutex
+	tokens  map[string]oauth2Token
+}
+
 // RunTest consumes an API test to be run against the configured server
-// produces a RunReport of the results of the test.
-func RunTest(test builder.APITest) RunReport {
-	report := RunReport{
-		Successful: false,
-		Test:       test,
+// produces a RunReport of the results of the test. Secrets used to authenticate
+// the request are redacted from the errors of the report.
+func (r *Runner) RunTest(test builder.APITest) RunReport {
+	report := r.runTest(test)
+
+	for n := 1; n <= r.Reruns && report.Status == StatusFailed; n++ {
+		report = r.runTest(test)
+		report.Reruns = n
+
+		if report.Successful {
+			report.Status = StatusFlaky
+		}
+	}
+
+	secrets := r.secrets(test.Request.Auth)
+	if len(secrets) == 0 {
+		return report
+	}
+
+	if len(report.Failures) > 0 {
+		for i, failure := range report.Failures {
+			report.Failures[i] = redact(failure, secrets)
+		}
+		report.Error = report.Failures
+	} else {
+		report.Error = redact(report.Error, secrets)
+	}
+
+	return report
+}
+
+// client produces the client used to send requests.
+func (r *Runner) client() *http.Client {
+	if r.Client == nil {
+		return http.DefaultClient
 	}
 
-	// TODO: Will eventually load a bunch of http client config (i.e. custom timeout)
-	client := &http.Client{}
+	return r.Client
+}
+
+// tokenClient produces the client used to request OAuth2 tokens.
+func (r *Runner) tokenClient() *http.Client {
+	if r.TokenClient == nil {
+		return r.client()
+	}
 
+	return r.TokenClient
+}
+
+// send builds the request of a test and sends it using client. When refresh is
+// true a new OAuth2 token is acquired for the request. The boolean result
+// reports whether a cached OAuth2 token was reused for the request.
+func (r *Runner) send(client *http.Client, test builder.APITest, refresh bool) (*http.Response, bool, error) {
 	req, err := buildRequest(test)
 	if err != nil {
-		report.Error = err
-		return report
+		return nil, false, err
+	}
+
+	// Tokens are acquired outside of the test's session.
+	reused, err := r.authorize(r.tokenClient(), req, test.Request.Auth, refresh)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Signing must happen last as the signature covers the final request.
+	if r.Signer != nil {
+		if err := r.Signer.Sign(req); err != nil {
+			return nil, false, err
+		}
 	}
 
 	resp, err := client.Do(req)
+	return resp, reused, err
+}
+
+// runTest runs a single test, attempting it more than once if it should be
+// retried.
+func (r *Runner) runTest(test builder.APITest) RunReport {
+	if test.Skip != "" {
+		return RunReport{Status: StatusSkipped, Test: test}
+	}
+
+	// The client is created once so a session is only reset before the first
+	// attempt.
+	client := redirectClient(r.sessionClient(r.client(), test), test, r.MaxRedirects)
+
+	if test.Retry == nil && test.Eventually == nil {
+		report, _ := r.attempt(client, test)
+		report.Attempts = 1
+		return report
+	}
+
+	return r.retry(client, test)
+}
+
+// attempt sends the request of a single test and asserts the response. The
+// status code of the response is also produced, 0 if none was received.
+func (r *Runner) attempt(client *http.Client, test builder.APITest) (RunReport, int) {
+	report := RunReport{
+		Status:     StatusFailed,
+		Successful: false,
+		Test:       test,
+	}
+
+	resp, reused, err := r.send(client, test, false)
 	if err != nil {
 		report.Error = err
-		return report
+		return report, 0
 	}
 
-	report.Successful, report.Error = assertResponse(resp, test.Response)
+	// A cached OAuth2 token may be revoked before it expires, so the request is
+	// retried once with a new token. A token acquired for this request is never
+	// stale, so its rejection is the server's response.
+	if resp.StatusCode == http.StatusUnauthorized && reused {
+		resp.Body.Close()
 
-	return report
+		if resp, _, err = r.send(client, test, true); err != nil {
+			report.Error = err
+			return report, 0
+		}
+	}
+	defer resp.Body.Close()
+
+	report.Failures = assertResponse(resp, test.Response)
+	if len(report.Failures) > 0 {
+		report.Error = report.Failures
+		return report, resp.StatusCode
+	}
+
+	report.Status = StatusPassed
+	report.Successful = true
+
+	return report, resp.StatusCode
+}
+
+// Focus consumes a slice of APITests and, if any of them are marked as only,
+// produces a copy where every test not marked as only is skipped.
+func Focus(tests []builder.APITest) []builder.APITest {
+	focused := false
+	for _, test := range tests {
+		if test.Only {
+			focused = true
+			break
+		}
+	}
+
+	if !focused {
+		return tests
+	}
+
+	result := make([]builder.APITest, len(tests))
+	for i, test := range tests {
+		if !test.Only && test.Skip == "" {
+			test.Skip = notOnlyReason
+		}
+		result[i] = test
+	}
+
+	return result
 }
 
 // RunTests consumes a slice of APITests, runs each test and produces
-// a slice of RunReports for each test that is ran.
-func RunTests(tests []builder.APITest) []RunReport {
+// a slice of RunReports for each test that is ran. Tests are focused
+// before running, see Focus.
+func (r *Runner) RunTests(tests []builder.APITest) []RunReport {
+	tests = Focus(tests)
 	reports := make([]RunReport, len(tests))
 
 	for i, test := range tests {
-		reports[i] = RunTest(test)
+		reports[i] = r.RunTest(test)
+
+		if r.Progress != nil {
+			r.Progress(reports[i], i+1, len(tests))
+		}
 	}
 
 	return reports
 }
+
+// RunTest runs the given test using a default Runner.
+func RunTest(test builder.APITest) RunReport {
+	r := Runner{}
+	return r.RunTest(test)
+}
+
+// RunTests runs each of the given tests using a default Runner.
+func RunTests(tests []builder.APITest) []RunReport {
+	r := Runner{}
+	return r.RunTests(tests)
+}
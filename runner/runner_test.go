@@ -166,22 +166,22 @@ var (
 
 	basicAPI = builder.APIResponse{
 		Body: "test",
-		Headers: map[string]string{
-			"Content-Type": "application/json",
+		Headers: map[string]builder.HeaderMatcher{
+			"Content-Type": builder.HeaderValue("application/json"),
 		},
 		StatusCode: http.StatusOK,
 	}
 
 	noBodyAPI = builder.APIResponse{
-		Headers: map[string]string{
-			"Content-Type": "application/json",
+		Headers: map[string]builder.HeaderMatcher{
+			"Content-Type": builder.HeaderValue("application/json"),
 		},
 		StatusCode: http.StatusOK,
 	}
 
 	emptyJSONAPI = builder.APIResponse{
-		Headers: map[string]string{
-			"Content-Type": "application/json",
+		Headers: map[string]builder.HeaderMatcher{
+			"Content-Type": builder.HeaderValue("application/json"),
 		},
 		JSON:       "",
 		StatusCode: http.StatusOK,
@@ -304,7 +304,7 @@ var assertResponseTests = []struct {
 
 func TestAssertResponse(t *testing.T) {
 	for _, test := range assertResponseTests {
-		if ok, _ := assertResponse(test.actual, test.expected); ok != test.succeed {
+		if failures := assertResponse(test.actual, test.expected); (len(failures) == 0) != test.succeed {
 			succeedText := "passed"
 			if !test.succeed {
 				succeedText = "failed"
@@ -314,6 +314,57 @@ func TestAssertResponse(t *testing.T) {
 	}
 }
 
+func TestAssertResponseReportsEveryFailure(t *testing.T) {
+	resp := http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("mismatching")),
+	}
+
+	expected := builder.APIResponse{
+		Body: "test",
+		Headers: map[string]builder.HeaderMatcher{
+			"Content-Type": builder.HeaderValue("application/json"),
+			"X-Request-Id": builder.HeaderValue("1"),
+		},
+		StatusCode: http.StatusOK,
+	}
+
+	// Status code, body and both headers should all be reported.
+	if failures := assertResponse(&resp, expected); len(failures) != 4 {
+		t.Errorf("expected 4 failures but received %v: %v", len(failures), failures)
+	}
+}
+
+func TestAssertResponseBodyLength(t *testing.T) {
+	zero, length := int64(0), int64(4)
+
+	tests := []struct {
+		body          string
+		contentLength int64
+		expected      builder.APIResponse
+		succeed       bool
+	}{
+		{"", 0, builder.APIResponse{StatusCode: http.StatusOK, EmptyBody: true}, true},
+		{"test", 4, builder.APIResponse{StatusCode: http.StatusOK, EmptyBody: true}, false},
+		{"", 0, builder.APIResponse{StatusCode: http.StatusOK, ContentLength: &zero}, true},
+		{"test", 4, builder.APIResponse{StatusCode: http.StatusOK, ContentLength: &length}, true},
+		{"test", -1, builder.APIResponse{StatusCode: http.StatusOK, ContentLength: &length}, true}, // Unknown length uses the body
+		{"test", 4, builder.APIResponse{StatusCode: http.StatusOK, ContentLength: &zero}, false},
+	}
+
+	for _, test := range tests {
+		resp := http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: test.contentLength,
+			Body:          ioutil.NopCloser(bytes.NewBufferString(test.body)),
+		}
+
+		if failures := assertResponse(&resp, test.expected); (len(failures) == 0) != test.succeed {
+			t.Errorf("Received unexpected failures %v for body %q and expected %+v", failures, test.body, test.expected)
+		}
+	}
+}
+
 var buildQueryStringTests = []struct {
 	input    map[string]string
 	expected []string
@@ -416,3 +467,29 @@ func TestAssertJSONType(t *testing.T) {
 		}
 	}
 }
+
+var focusTests = []struct {
+	tests   []builder.APITest
+	skipped []bool
+}{
+	{[]builder.APITest{{}, {}}, []bool{false, false}},
+	{[]builder.APITest{{Only: true}, {}}, []bool{false, true}},
+	{[]builder.APITest{{Only: true}, {Skip: "flaky"}, {Only: true}}, []bool{false, true, false}},
+}
+
+func TestFocus(t *testing.T) {
+	for i, test := range focusTests {
+		for j, focused := range Focus(test.tests) {
+			if (focused.Skip != "") != test.skipped[j] {
+				t.Errorf("test #%v: expected test %v skipped to be %v", i, j, test.skipped[j])
+			}
+		}
+	}
+}
+
+func TestRunTestSkipped(t *testing.T) {
+	report := RunTest(builder.APITest{Skip: "flaky", Hostname: "http://invalid.invalid"})
+	if report.Status != StatusSkipped || report.Error != nil {
+		t.Errorf("expected skipped test to not be run: %+v", report)
+	}
+}
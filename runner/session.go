@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// session produces the cookie jar of the named session, creating it the first
+// time it is used. When reset is true the session's cookies are cleared first.
+func (r *Runner) session(name string, reset bool) http.CookieJar {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sessions == nil {
+		r.sessions = make(map[string]http.CookieJar)
+	}
+
+	jar, ok := r.sessions[name]
+	if !ok || reset {
+		// cookiejar.New only fails when given invalid options.
+		jar, _ = cookiejar.New(nil)
+		r.sessions[name] = jar
+	}
+
+	return jar
+}
+
+// ResetSessions clears the cookies of every session, so the following tests
+// start without any cookies like a new browser.
+func (r *Runner) ResetSessions() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions = nil
+}
+
+// sessionClient produces a copy of client storing cookies in the jar of the
+// test's session, or client itself if the test does not belong to a session.
+func (r *Runner) sessionClient(client *http.Client, test builder.APITest) *http.Client {
+	if test.Session == "" {
+		return client
+	}
+
+	c := *client
+	c.Jar = r.session(test.Session, test.ResetSession)
+
+	return &c
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JonathonGore/api-check/builder"
+)
+
+// sessionHandler logs in by setting a cookie and reports whether later
+// requests send it.
+var sessionHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/login" {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		return
+	}
+
+	if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc" {
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+})
+
+func sessionTest(endpoint, session string, code int) builder.APITest {
+	return builder.APITest{
+		Method:   http.MethodGet,
+		Hostname: "http://localhost",
+		Endpoint: endpoint,
+		Session:  session,
+		Response: builder.APIResponse{StatusCode: code},
+	}
+}
+
+func TestRunTestSessions(t *testing.T) {
+	r := Runner{Client: HandlerClient(sessionHandler)}
+
+	reset := sessionTest("/me", "user", http.StatusUnauthorized)
+	reset.ResetSession = true
+
+	tests := []builder.APITest{
+		sessionTest("/me", "user", http.StatusUnauthorized),
+		sessionTest("/login", "user", http.StatusOK),
+		sessionTest("/me", "user", http.StatusOK),            // Cookie flows from the login test
+		sessionTest("/me", "", http.StatusUnauthorized),      // Tests without a session have no cookies
+		sessionTest("/me", "admin", http.StatusUnauthorized), // Sessions are independent
+		reset, // Resetting clears the cookies
+		sessionTest("/me", "user", http.StatusUnauthorized),
+	}
+
+	for i, test := range tests {
+		if report := r.RunTest(test); !report.Successful {
+			t.Errorf("Expected test #%v to succeed but it failed with: %v", i+1, report.Error)
+		}
+	}
+
+	r.RunTest(sessionTest("/login", "user", http.StatusOK))
+	r.ResetSessions()
+	if report := r.RunTest(sessionTest("/me", "user", http.StatusUnauthorized)); !report.Successful {
+		t.Errorf("Expected sessions to be cleared but test failed with: %v", report.Error)
+	}
+}
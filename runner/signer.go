@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JonathonGore/api-check/config"
+)
+
+// Signer signs a request once it has been built, just before it is sent.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// hashes are the algorithms an HMACSigner supports.
+var hashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// encodings are the ways an HMACSigner may encode a signature.
+var encodings = map[string]func(src []byte) string{
+	"hex":    hex.EncodeToString,
+	"base64": base64.StdEncoding.EncodeToString,
+}
+
+// HMACSigner signs requests with an HMAC of a canonical string built from the
+// request. It should be created with NewHMACSigner.
+type HMACSigner struct {
+	conf   config.Signing
+	hash   func() hash.Hash
+	encode func(src []byte) string
+
+	// now produces the time used as the timestamp of a signature.
+	now func() time.Time
+}
+
+// NewHMACSigner creates a signer from the signing config, using the default of
+// each setting that is not given.
+func NewHMACSigner(conf config.Signing) (*HMACSigner, error) {
+	if conf.Header == "" {
+		conf.Header = config.DefaultSigningHeader
+	}
+	if conf.Algorithm == "" {
+		conf.Algorithm = config.DefaultSigningAlgorithm
+	}
+	if conf.Template == "" {
+		conf.Template = config.DefaultSigningTemplate
+	}
+	if conf.Encoding == "" {
+		conf.Encoding = config.DefaultSigningEncoding
+	}
+
+	h, ok := hashes[strings.ToLower(conf.Algorithm)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing algorithm: %v", conf.Algorithm)
+	}
+
+	encode, ok := encodings[strings.ToLower(conf.Encoding)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signature encoding: %v", conf.Encoding)
+	}
+
+	if conf.Secret == "" {
+		return nil, fmt.Errorf("signing requires a secret")
+	}
+
+	return &HMACSigner{conf: conf, hash: h, encode: encode, now: time.Now}, nil
+}
+
+// canonical produces the string signed for the request.
+func (s *HMACSigner) canonical(req *http.Request, timestamp string) (string, error) {
+	var body []byte
+	if req.GetBody != nil {
+		// Read a copy so the body can still be sent.
+		reader, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer reader.Close()
+
+		if body, err = ioutil.ReadAll(reader); err != nil {
+			return "", err
+		}
+	}
+
+	sum := sha256.Sum256(body)
+
+	replacer := strings.NewReplacer(
+		"{method}", req.Method,
+		"{host}", req.URL.Host,
+		"{path}", req.URL.EscapedPath(),
+		"{query}", req.URL.RawQuery,
+		"{timestamp}", timestamp,
+		"{content-type}", req.Header.Get("Content-Type"),
+		"{body}", string(body),
+		"{body-sha256}", hex.EncodeToString(sum[:]),
+	)
+
+	return replacer.Replace(s.conf.Template), nil
+}
+
+// Sign adds the signature of the request, and its timestamp if configured, to
+// the request's headers.
+func (s *HMACSigner) Sign(req *http.Request) error {
+	secret, err := expandEnv(s.conf.Secret)
+	if err != nil {
+		return fmt.Errorf("unable to sign request: %v", err)
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	canonical, err := s.canonical(req, timestamp)
+	if err != nil {
+		return fmt.Errorf("unable to sign request: %v", err)
+	}
+
+	mac := hmac.New(s.hash, []byte(secret))
+	mac.Write([]byte(canonical))
+
+	if s.conf.TimestampHeader != "" {
+		req.Header.Set(s.conf.TimestampHeader, timestamp)
+	}
+	req.Header.Set(s.conf.Header, s.conf.Prefix+s.encode(mac.Sum(nil)))
+
+	return nil
+}
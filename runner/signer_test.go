@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/api-check/config"
+)
+
+func TestHMACSigner(t *testing.T) {
+	os.Setenv("API_CHECK_TEST_SECRET", "secret")
+	defer os.Unsetenv("API_CHECK_TEST_SECRET")
+
+	sign := func(canonical string) []byte {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(canonical))
+		return mac.Sum(nil)
+	}
+
+	sum := sha256.Sum256([]byte(`{"name":"Jack"}`))
+
+	tests := []struct {
+		conf     config.Signing
+		header   string
+		expected string
+	}{
+		{
+			config.Signing{Secret: "secret"},
+			"X-Signature",
+			hex.EncodeToString(sign("POST\n/users\n1500000000\n{\"name\":\"Jack\"}")),
+		},
+		{
+			config.Signing{
+				Header:          "Authorization",
+				Prefix:          "HMAC-SHA256 ",
+				Secret:          "${ENV:API_CHECK_TEST_SECRET}",
+				Template:        "{method} {host}{path}?{query} {timestamp} {content-type} {body-sha256}",
+				TimestampHeader: "X-Timestamp",
+				Encoding:        "base64",
+			},
+			"Authorization",
+			"HMAC-SHA256 " + base64.StdEncoding.EncodeToString(sign("POST localhost/users?page=1 1500000000 application/json "+hex.EncodeToString(sum[:]))),
+		},
+	}
+
+	for _, test := range tests {
+		signer, err := NewHMACSigner(test.conf)
+		if err != nil {
+			t.Fatalf("Received unexpected error creating signer: %v", err)
+		}
+		signer.now = func() time.Time { return time.Unix(1500000000, 0) }
+
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost/users?page=1", bytes.NewBufferString(`{"name":"Jack"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		if err := signer.Sign(req); err != nil {
+			t.Fatalf("Received unexpected error signing request: %v", err)
+		}
+
+		if signature := req.Header.Get(test.header); signature != test.expected {
+			t.Errorf("Expected signature %v but received %v", test.expected, signature)
+		}
+
+		if test.conf.TimestampHeader != "" && req.Header.Get(test.conf.TimestampHeader) != "1500000000" {
+			t.Errorf("Expected timestamp header but received %v", req.Header.Get(test.conf.TimestampHeader))
+		}
+
+		// The body must still be sent.
+		if body, _ := ioutil.ReadAll(req.Body); string(body) != `{"name":"Jack"}` {
+			t.Errorf("Expected body to be unchanged but received %v", string(body))
+		}
+	}
+}
+
+func TestNewHMACSignerErrors(t *testing.T) {
+	confs := []config.Signing{
+		{},
+		{Secret: "secret", Algorithm: "md5"},
+		{Secret: "secret", Encoding: "base32"},
+	}
+
+	for _, conf := range confs {
+		if _, err := NewHMACSigner(conf); err == nil {
+			t.Errorf("Expected an error creating a signer from %+v", conf)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package suite
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/JonathonGore/api-check/builder"
+	"github.com/JonathonGore/api-check/filter"
+	"github.com/JonathonGore/api-check/runner"
+)
+
+// Option configures a Suite.
+type Option func(*Suite)
+
+// WithDir sets the directory test definitions are loaded from. Defaults to
+// the current working directory.
+func WithDir(dir string) Option {
+	return func(s *Suite) {
+		s.dir = dir
+	}
+}
+
+// WithConfigFile sets the path of the api-check config file, which must exist.
+// Defaults to the optional config.DefaultConfigFile stored in the suite's
+// directory.
+func WithConfigFile(path string) Option {
+	return func(s *Suite) {
+		s.configFile = path
+	}
+}
+
+// WithPaths sets the files and directories test definitions are loaded from,
+// instead of every definition below the suite's directory.
+func WithPaths(paths ...string) Option {
+	return func(s *Suite) {
+		s.paths = paths
+	}
+}
+
+// WithLenient determines if problems finding test definitions, such as
+// unreadable directories, are reported as warnings instead of failing the run.
+func WithLenient(lenient bool) Option {
+	return func(s *Suite) {
+		s.lenient = lenient
+	}
+}
+
+// WithHTTPClient sets the client used to send the request of every test.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Suite) {
+		s.client = client
+	}
+}
+
+// WithHandler serves every request with the given handler in-process instead
+// of sending it over the network. The hostname of each test is ignored and
+// becomes optional.
+func WithHandler(handler http.Handler) Option {
+	return func(s *Suite) {
+		s.client = runner.HandlerClient(handler)
+		s.inProcess = true
+	}
+}
+
+// WithServer sends every request to the given test server regardless of the
+// hostname of each test, which becomes optional.
+func WithServer(server *httptest.Server) Option {
+	return func(s *Suite) {
+		s.server = server
+		s.inProcess = true
+	}
+}
+
+// WithSigner sets the signer used to sign every request, instead of the HMAC
+// signer described by the signing section of the config file.
+func WithSigner(signer runner.Signer) Option {
+	return func(s *Suite) {
+		s.signer = signer
+	}
+}
+
+// WithReruns sets how many times a failed test is run again. Tests which pass
+// when run again are reported as flaky rather than failed.
+func WithReruns(n int) Option {
+	return func(s *Suite) {
+		s.reruns = n
+	}
+}
+
+// WithReporter adds a reporter which receives the results of the run. May be
+// given multiple times.
+func WithReporter(reporter Reporter) Option {
+	return func(s *Suite) {
+		s.reporters = append(s.reporters, reporter)
+	}
+}
+
+// WithFilter sets the filter used to select which of the loaded tests are run.
+func WithFilter(f filter.Filter) Option {
+	return func(s *Suite) {
+		s.filter = f
+	}
+}
+
+// WithBeforeAll adds a hook run after the setup script but before any test.
+// An error aborts the run.
+func WithBeforeAll(hook func() error) Option {
+	return func(s *Suite) {
+		s.beforeAll = append(s.beforeAll, hook)
+	}
+}
+
+// WithAfterAll adds a hook run after every test but before the cleanup script.
+func WithAfterAll(hook func() error) Option {
+	return func(s *Suite) {
+		s.afterAll = append(s.afterAll, hook)
+	}
+}
+
+// WithBeforeEach adds a hook run before each test, it may modify the test.
+func WithBeforeEach(hook func(test *builder.APITest)) Option {
+	return func(s *Suite) {
+		s.beforeEach = append(s.beforeEach, hook)
+	}
+}
+
+// WithAfterEach adds a hook run with the report of each test after it runs.
+func WithAfterEach(hook func(report runner.RunReport)) Option {
+	return func(s *Suite) {
+		s.afterEach = append(s.afterEach, hook)
+	}
+}
@@ -2,56 +2,122 @@ package suite
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/JonathonGore/api-check/builder"
 	"github.com/JonathonGore/api-check/config"
+	"github.com/JonathonGore/api-check/filter"
 	"github.com/JonathonGore/api-check/loader"
 	"github.com/JonathonGore/api-check/parser"
 	"github.com/JonathonGore/api-check/printer"
 	"github.com/JonathonGore/api-check/runner"
 )
 
-// TODO: Remove this in favour of Config
-type RunConfig struct {
-	verbose          bool
-	standalone       bool
-	muteScriptOutput bool
+// inProcessHostname is the default hostname used for tests when requests are
+// routed to a handler or test server.
+const inProcessHostname = "http://localhost"
+
+// Reporter receives the results of a test run as it progresses.
+type Reporter interface {
+	// Start is called once before any test is run.
+	Start(total int)
+
+	// Progress is called after each test is run with its report along with
+	// the number of tests completed so far and the total to run.
+	Progress(report runner.RunReport, done, total int)
+
+	// Report is called once every test has been run.
+	Report(reports []runner.RunReport)
 }
 
-var (
-	rconf = RunConfig{}
-)
+// Result describes the outcome of running a Suite.
+type Result struct {
+	Reports []runner.RunReport
+
+	// Warnings are problems that did not prevent the suite from running, such
+	// as unknown fields in test definitions or discovery errors in lenient mode.
+	Warnings []error
+
+	Passed  int
+	Failed  int
+	Skipped int
+
+	// Flaky counts the tests which failed but passed when run again, they are
+	// not counted as passed.
+	Flaky int
+}
 
-// Printf prints fmt.Printf statements only run config's verbose setting is true
-func printf(text string, args ...interface{}) {
-	if rconf.verbose {
-		fmt.Printf(text, args...)
+// Successful determines if no test in the run failed. Flaky tests do not fail
+// the run.
+func (r Result) Successful() bool {
+	return r.Failed == 0
+}
+
+// add records the report of a single test in the result.
+func (r *Result) add(report runner.RunReport) {
+	r.Reports = append(r.Reports, report)
+
+	switch {
+	case report.Status == runner.StatusSkipped:
+		r.Skipped++
+	case report.Status == runner.StatusFlaky:
+		r.Flaky++
+	case report.Error != nil:
+		r.Failed++
+	default:
+		r.Passed++
 	}
 }
 
-// Verbose accepts a boolean argument and sets the logging level accordingly
-func Verbose(isVerbose bool) {
-	rconf.verbose = isVerbose
+// Suite loads test definitions and runs them. A Suite should be created
+// with New.
+type Suite struct {
+	dir        string
+	configFile string
+	paths      []string
+	client     *http.Client
+	server     *httptest.Server
+	inProcess  bool
+	lenient    bool
+	reporters  []Reporter
+	filter     filter.Filter
+	signer     runner.Signer
+	reruns     int
+	beforeAll  []func() error
+	afterAll   []func() error
+	beforeEach []func(test *builder.APITest)
+	afterEach  []func(report runner.RunReport)
 }
 
-// MuteScriptOutput determines if output from cleanup and setup scripts should
-// surpressed.
-func MuteScriptOutput(muteScriptOutput bool) {
-	rconf.muteScriptOutput = muteScriptOutput
+// New creates a Suite configured with the given options.
+func New(opts ...Option) *Suite {
+	s := &Suite{}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // runScript will execute the bash script in the given filename if non empty.
-func runScript(filename string) error {
+// The script is run from within dir.
+func runScript(filename, dir string, mute bool) error {
 	if len(filename) == 0 {
 		return nil
 	}
 
 	cmd := exec.Command("/bin/bash", filename)
+	cmd.Dir = dir
 
 	// Only redirect output if unmuted.
-	if !rconf.muteScriptOutput {
+	if !mute {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 	}
@@ -66,88 +132,368 @@ func runScript(filename string) error {
 	return nil
 }
 
-func run(t *testing.T) error {
+// directory produces the directory test definitions are loaded from.
+func (s *Suite) directory() (string, error) {
+	if s.dir != "" {
+		return s.dir, nil
+	}
+
 	// For now we default the directory we look in for test definitions to be cwd.
-	dir, err := os.Getwd()
+	return os.Getwd()
+}
+
+// configPath produces the path of the config file, by default the config
+// file stored in the suite's directory.
+func (s *Suite) configPath() (string, error) {
+	if s.configFile != "" {
+		return s.configFile, nil
+	}
+
+	dir, err := s.directory()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// This loads the files containing step definitions below the working directory.
-	// Allows the user to have multiple files containing api testing definitions
-	files, err := loader.FindTestDefinitions(dir)
+	return config.DefaultPath(dir), nil
+}
+
+// load reads the given config file and parses every selected test definition.
+// Problems which do not prevent the suite from running are returned as warnings.
+func (s *Suite) load(configPath string) (config.Config, []builder.APITest, []error, error) {
+	var warnings []error
+
+	dir, err := s.directory()
 	if err != nil {
-		return fmt.Errorf("unable to find test definition files: %v", err)
+		return config.Config{}, nil, warnings, err
+	}
+
+	paths := s.paths
+	if len(paths) == 0 {
+		paths = []string{dir}
+	}
+
+	// A config file named explicitly must exist, the default one is optional.
+	readConfig := config.New
+	if s.configFile != "" {
+		readConfig = config.Read
 	}
 
-	// TODO: It will likely be a good idea to allow the user to specify an alternate
-	// config file.
-	conf, err := config.New(config.DefaultConfigFile)
+	conf, err := readConfig(configPath)
 	if err != nil {
-		return fmt.Errorf("unable to parse config file: %v", err)
+		return conf, nil, warnings, fmt.Errorf("unable to parse config file: %v", err)
 	}
 
-	// TODO: This will be removed after we remove RunConfig from existence.
-	rconf.muteScriptOutput = conf.MuteScriptOutput
+	// This loads the files containing step definitions below the working directory.
+	// Allows the user to have multiple files containing api testing definitions
+	files, err := loader.New(conf).FindAll(paths)
+	if errs, ok := err.(loader.Errors); ok && s.lenient {
+		warnings = append(warnings, errs...)
+	} else if err != nil {
+		return conf, nil, warnings, fmt.Errorf("unable to find test definition files:\n%v", err)
+	}
+
+	// Requests sent in-process never leave the process so tests need not name
+	// a real hostname.
+	if s.inProcess && conf.Hostname == "" {
+		conf.Hostname = inProcessHostname
+	}
 
 	p := parser.New(conf)
 
 	tests, err := p.Parse(files)
+	warnings = append(warnings, p.Warnings()...)
+	if err != nil {
+		return conf, nil, warnings, err
+	}
+
+	tests, err = s.filter.Apply(tests)
 	if err != nil {
-		return fmt.Errorf("%v", err)
+		return conf, nil, warnings, err
+	}
+
+	return conf, runner.Focus(tests), warnings, nil
+}
+
+// resolvePath produces path relative to dir, unless path is empty or absolute.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
 	}
 
-	if err := runScript(conf.SetupScript); err != nil {
-		return fmt.Errorf("unable to run setup script: %v", err)
+	return filepath.Join(dir, path)
+}
+
+// newRunner creates the runner used to run every test. Files named by the
+// config are relative to configDir.
+func (s *Suite) newRunner(conf config.Config, configDir string) (*runner.Runner, error) {
+	r := &runner.Runner{
+		Client:       s.client,
+		Signer:       s.signer,
+		Reruns:       s.reruns,
+		MaxRedirects: conf.HTTP.MaxRedirects,
 	}
 
-	printf("Running go api-check\n\n")
-	reports := runner.RunTests(tests)
+	if r.Signer == nil && conf.Signing != nil {
+		signer, err := runner.NewHMACSigner(*conf.Signing)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create request signer: %v", err)
+		}
+		r.Signer = signer
+	}
 
-	if rconf.verbose {
-		printer.PrintReports(reports)
+	if s.server != nil {
+		client, err := runner.ServerClient(s.server)
+		if err != nil {
+			return nil, err
+		}
+		r.Client = client
 	}
 
-	if err := runScript(conf.CleanupScript); err != nil {
-		return fmt.Errorf("unable to run cleanup script: %v", err)
+	// Without a client of its own one client is created for the whole run, so
+	// connections are reused between tests.
+	if r.Client == nil || s.inProcess {
+		httpConf := conf.HTTP
+		httpConf.CABundle = resolvePath(configDir, httpConf.CABundle)
+		httpConf.ClientCert = resolvePath(configDir, httpConf.ClientCert)
+		httpConf.ClientKey = resolvePath(configDir, httpConf.ClientKey)
+
+		client, err := runner.NewClient(httpConf)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create http client: %v", err)
+		}
+
+		// In-process clients send every request to the app, so OAuth2 tokens
+		// are requested from the real token endpoint with the configured client.
+		if r.Client == nil {
+			r.Client = client
+		} else {
+			r.TokenClient = client
+		}
+	}
+
+	return r, nil
+}
+
+// runTest runs a single test surrounded by the before and after each hooks.
+func (s *Suite) runTest(r *runner.Runner, test builder.APITest) runner.RunReport {
+	for _, hook := range s.beforeEach {
+		hook(&test)
+	}
+
+	report := r.RunTest(test)
+
+	for _, hook := range s.afterEach {
+		hook(report)
+	}
+
+	return report
+}
+
+// execute runs the setup script and before all hooks, then each of the tests
+// using run, followed by the after all hooks and cleanup script. Once the setup
+// script has run the cleanup script always runs, even when a hook fails.
+func (s *Suite) execute(run func(conf config.Config, r *runner.Runner, tests []builder.APITest) Result) (result Result, err error) {
+	configPath, err := s.configPath()
+	if err != nil {
+		return Result{}, err
 	}
 
-	for _, report := range reports {
-		if report.Error != nil {
-			if t != nil {
-				t.Errorf("%v", report.Error) // Signal to go test we have failed a test
-			} else {
-				// Only exit when running in standalone mode
-				os.Exit(1)
+	conf, tests, warnings, err := s.load(configPath)
+	if err != nil {
+		return Result{Warnings: warnings}, err
+	}
+
+	// Scripts and other files are named relative to the config file that
+	// refers to them.
+	configDir := filepath.Dir(configPath)
+
+	r, err := s.newRunner(conf, configDir)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := runScript(conf.SetupScript, configDir, conf.MuteScriptOutput); err != nil {
+		return Result{}, fmt.Errorf("unable to run setup script: %v", err)
+	}
+
+	defer func() {
+		if cleanupErr := runScript(conf.CleanupScript, configDir, conf.MuteScriptOutput); cleanupErr != nil && err == nil {
+			err = fmt.Errorf("unable to run cleanup script: %v", cleanupErr)
+		}
+	}()
+
+	for _, hook := range s.beforeAll {
+		if err := hook(); err != nil {
+			return Result{}, fmt.Errorf("before all hook failed: %v", err)
+		}
+	}
+
+	for _, reporter := range s.reporters {
+		reporter.Start(len(tests))
+	}
+
+	result = run(conf, r, tests)
+	result.Warnings = warnings
+
+	for _, reporter := range s.reporters {
+		reporter.Report(result.Reports)
+	}
+
+	for _, hook := range s.afterAll {
+		if err := hook(); err != nil {
+			return result, fmt.Errorf("after all hook failed: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// Run runs every test in order and produces the result of the run. An error
+// is only returned when the suite itself could not be run, failing tests are
+// described by the result.
+func (s *Suite) Run() (Result, error) {
+	return s.execute(func(conf config.Config, r *runner.Runner, tests []builder.APITest) Result {
+		var result Result
+
+		for i, test := range tests {
+			report := s.runTest(r, test)
+			result.add(report)
+
+			for _, reporter := range s.reporters {
+				reporter.Progress(report, i+1, len(tests))
 			}
 		}
+
+		return result
+	})
+}
+
+// subtestName produces the name of the subtest grouping every test defined in
+// file, which is the path of file relative to dir.
+func subtestName(dir, file string) string {
+	if rel, err := filepath.Rel(dir, file); err == nil {
+		return filepath.ToSlash(rel)
 	}
 
-	return nil
+	return filepath.ToSlash(file)
+}
+
+// Test runs each test as a go test subtest of t, grouped by the file it was
+// defined in. This allows a single test to be targeted with `go test -run`,
+// for example `go test -run 'TestMain/users.ac.json/create_user'`. Tests are
+// run in parallel when enabled in the config file.
+func (s *Suite) Test(t *testing.T) (Result, error) {
+	dir, err := s.directory()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return s.execute(func(conf config.Config, r *runner.Runner, tests []builder.APITest) Result {
+		var mu sync.Mutex
+		var result Result
+
+		// Group tests by file while preserving the order they were defined in.
+		var files []string
+		groups := make(map[string][]builder.APITest)
+		for _, test := range tests {
+			if _, ok := groups[test.File]; !ok {
+				files = append(files, test.File)
+			}
+			groups[test.File] = append(groups[test.File], test)
+		}
+
+		for _, file := range files {
+			group := groups[file]
+
+			t.Run(subtestName(dir, file), func(t *testing.T) {
+				for _, test := range group {
+					test := test
+
+					t.Run(printer.Description(test), func(t *testing.T) {
+						if conf.Parallel {
+							t.Parallel()
+						}
+
+						report := s.runTest(r, test)
+
+						mu.Lock()
+						result.add(report)
+						for _, reporter := range s.reporters {
+							reporter.Progress(report, len(result.Reports), len(tests))
+						}
+						mu.Unlock()
+
+						switch {
+						case report.Status == runner.StatusSkipped:
+							t.Skip(test.Skip)
+						case report.Status == runner.StatusFlaky:
+							t.Logf("flaky: passed after %v reruns", report.Reruns)
+						case len(report.Failures) > 0:
+							for _, failure := range report.Failures {
+								t.Error(failure)
+							}
+						case report.Error != nil:
+							t.Error(report.Error)
+						}
+					})
+				}
+			})
+		}
+
+		return result
+	})
 }
 
-// RunStandalone configures the runner to use os.Exit(1) if a failure test occurs, and then
-// runs the test suite.
-func RunStandalone() {
-	rconf.standalone = true
+// RunStandalone runs the test suite printing results to the terminal, unless
+// other reporters are given, and exits with os.Exit(1) if any test fails or
+// the suite cannot be run.
+func RunStandalone(opts ...Option) {
+	s := New(opts...)
+	if len(s.reporters) == 0 {
+		s.reporters = []Reporter{printer.NewTerminal(false)}
+	}
 
-	var t *testing.T // Pass in nil testing context
-	if err := run(t); err != nil {
+	result, err := s.Run()
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", warning)
+	}
+
+	if err != nil {
 		fmt.Printf("Error running tests: %v\n", err)
 		os.Exit(1)
 	}
+
+	if !result.Successful() {
+		os.Exit(1)
+	}
 }
 
 // Run reads in *.ac.json files below or in the current directory and runs the test suite
-// for each test in every file.
-func Run(t *testing.T) {
+// for each test in every file, each as a subtest of t.
+func Run(t *testing.T, opts ...Option) {
 	if t == nil {
 		return // Just return to avoid breaking the users `go test ./... command`
 	}
 
-	run(t)
-	if err := run(t); err != nil {
-		fmt.Printf("Error running tests: %v\n", err)
-		os.Exit(1)
+	result, err := New(opts...).Test(t)
+	for _, warning := range result.Warnings {
+		t.Logf("warning: %v", warning)
 	}
+
+	if err != nil {
+		t.Fatalf("Error running tests: %v", err)
+	}
+}
+
+// RunHandler runs the test suite like Run, but serves every request with the
+// given handler in-process instead of sending it over the network. The
+// hostname of each test is ignored and becomes optional.
+func RunHandler(t *testing.T, handler http.Handler, opts ...Option) {
+	Run(t, append([]Option{WithHandler(handler)}, opts...)...)
+}
+
+// RunServer runs the test suite like Run, but sends every request to the given
+// test server regardless of the hostname of each test.
+func RunServer(t *testing.T, server *httptest.Server, opts ...Option) {
+	Run(t, append([]Option{WithServer(server)}, opts...)...)
 }
@@ -0,0 +1,310 @@
+package suite
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JonathonGore/api-check/builder"
+	"github.com/JonathonGore/api-check/runner"
+)
+
+const definitions = `[
+	{"description": "pass", "endpoint": "/ok", "response": {"code": 200}},
+	{"description": "fail", "endpoint": "/missing", "response": {"code": 200}},
+	{"description": "skip", "endpoint": "/ok", "skip": "not ready"}
+]`
+
+var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ok" {
+		http.NotFound(w, r)
+		return
+	}
+
+	fmt.Fprintf(w, "ok")
+})
+
+// countingReporter records how many times each reporter method is called.
+type countingReporter struct {
+	started, progressed, reported int
+}
+
+func (c *countingReporter) Start(total int)                                   { c.started++ }
+func (c *countingReporter) Progress(report runner.RunReport, done, total int) { c.progressed++ }
+func (c *countingReporter) Report(reports []runner.RunReport)                 { c.reported++ }
+
+// writeDefinitions creates a temporary directory containing a single test
+// definition file.
+func writeDefinitions(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "suite")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "tests.ac.json"), []byte(definitions), 0644); err != nil {
+		t.Fatalf("unable to write test definitions: %v", err)
+	}
+
+	return dir
+}
+
+func TestSuiteRun(t *testing.T) {
+	dir := writeDefinitions(t)
+	defer os.RemoveAll(dir)
+
+	reporter := &countingReporter{}
+	var before, after int
+
+	s := New(
+		WithDir(dir),
+		WithHandler(handler),
+		WithReporter(reporter),
+		WithBeforeEach(func(test *builder.APITest) { before++ }),
+		WithAfterEach(func(report runner.RunReport) { after++ }),
+	)
+
+	result, err := s.Run()
+	if err != nil {
+		t.Fatalf("unexpected error running suite: %v", err)
+	}
+
+	if result.Passed != 1 || result.Failed != 1 || result.Skipped != 1 || result.Successful() {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if before != 3 || after != 3 {
+		t.Errorf("expected each hook to run 3 times, ran %v and %v times", before, after)
+	}
+
+	if reporter.started != 1 || reporter.progressed != 3 || reporter.reported != 1 {
+		t.Errorf("unexpected reporter calls: %+v", reporter)
+	}
+}
+
+func TestSuiteRunBeforeAllError(t *testing.T) {
+	dir := writeDefinitions(t)
+	defer os.RemoveAll(dir)
+
+	// The cleanup script records that it ran next to the config file.
+	conf := []byte(`{"cleanup-script": "cleanup.sh", "mute-script-output": true}`)
+	if err := ioutil.WriteFile(filepath.Join(dir, ".ac.json"), conf, 0644); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "cleanup.sh"), []byte("touch cleaned\n"), 0644); err != nil {
+		t.Fatalf("unable to write cleanup script: %v", err)
+	}
+
+	s := New(
+		WithDir(dir),
+		WithHandler(handler),
+		WithBeforeAll(func() error { return errors.New("database unavailable") }),
+	)
+
+	if _, err := s.Run(); err == nil {
+		t.Errorf("expected a failing before all hook to abort the run")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "cleaned")); err != nil {
+		t.Errorf("expected the cleanup script to run after the before all hook failed: %v", err)
+	}
+}
+
+func TestSuiteMissingConfigFile(t *testing.T) {
+	dir := writeDefinitions(t)
+	defer os.RemoveAll(dir)
+
+	s := New(
+		WithDir(dir),
+		WithConfigFile(filepath.Join(dir, "missing.json")),
+		WithHandler(handler),
+	)
+
+	if _, err := s.Run(); err == nil {
+		t.Errorf("expected a missing config file to abort the run")
+	}
+}
+
+func TestSuiteConfigInDir(t *testing.T) {
+	dir := writeDefinitions(t)
+	defer os.RemoveAll(dir)
+
+	// Tests do not name a hostname so parsing only succeeds when the config
+	// file in the suite's directory is used.
+	conf := []byte(`{"hostname": "http://localhost:3000"}`)
+	if err := ioutil.WriteFile(filepath.Join(dir, ".ac.json"), conf, 0644); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+
+	s := New(WithDir(dir), WithHTTPClient(runner.HandlerClient(handler)))
+	if _, err := s.Run(); err != nil {
+		t.Errorf("expected config file in the suite's directory to be used: %v", err)
+	}
+}
+
+func TestSuiteRunReruns(t *testing.T) {
+	dir := writeDefinitions(t)
+	defer os.RemoveAll(dir)
+
+	// The missing endpoint only fails the first time it is requested.
+	requests := 0
+	flaky := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			requests++
+			if requests == 1 {
+				http.NotFound(w, r)
+			}
+		}
+	})
+
+	result, err := New(WithDir(dir), WithHandler(flaky), WithReruns(1)).Run()
+	if err != nil {
+		t.Fatalf("unexpected error running suite: %v", err)
+	}
+
+	if result.Passed != 1 || result.Flaky != 1 || result.Failed != 0 || !result.Successful() {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestSuiteRunHandlerOAuth2(t *testing.T) {
+	dir := writeDefinitions(t)
+	defer os.RemoveAll(dir)
+
+	var tokens int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokens, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`)
+	}))
+	defer tokenServer.Close()
+
+	conf := fmt.Sprintf(`{"auth": {"oauth2": {"tokenUrl": %q, "clientId": "id", "clientSecret": "secret"}}}`, tokenServer.URL)
+	if err := ioutil.WriteFile(filepath.Join(dir, ".ac.json"), []byte(conf), 0644); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+
+	// The app only serves requests authorized with the token.
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	result, err := New(WithDir(dir), WithHandler(app)).Run()
+	if err != nil {
+		t.Fatalf("unexpected error running suite: %v", err)
+	}
+
+	if result.Passed != 1 || result.Failed != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if tokens != 1 {
+		t.Errorf("Expected the token server to receive 1 request but received %v", tokens)
+	}
+}
+
+// subtestsDirEnv names the directory of the suite run by TestSuiteTest when it
+// is run as a child process of itself.
+const subtestsDirEnv = "API_CHECK_SUBTESTS_DIR"
+
+func TestSuiteTest(t *testing.T) {
+	// Failing subtests fail the test running them, so the suite is run by a
+	// child process whose output is checked.
+	if dir := os.Getenv(subtestsDirEnv); dir != "" {
+		slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			handler.ServeHTTP(w, r)
+		})
+
+		var done int32
+		Run(t,
+			WithDir(dir),
+			WithHandler(slow),
+			WithAfterEach(func(report runner.RunReport) { atomic.AddInt32(&done, 1) }),
+			WithAfterAll(func() error {
+				fmt.Printf("after all: %v tests done\n", atomic.LoadInt32(&done))
+				return nil
+			}),
+		)
+		return
+	}
+
+	dir := writeDefinitions(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".ac.json"), []byte(`{"parallel": true}`), 0644); err != nil {
+		t.Fatalf("unable to write config file: %v", err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSuiteTest$", "-test.v")
+	cmd.Env = append(os.Environ(), subtestsDirEnv+"="+dir)
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("expected the failing test to fail the run but received: %v\n%s", err, out)
+	}
+
+	// Verbose output names the subtest running before the lines it logs, and
+	// the result of every subtest once they have all finished.
+	results := make(map[string]string)
+	logs := make(map[string][]string)
+	var name string
+	for _, line := range strings.Split(string(out), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch fields := strings.Fields(trimmed); {
+		case strings.HasPrefix(trimmed, "=== ") && len(fields) > 2:
+			name = fields[2]
+		case strings.HasPrefix(trimmed, "--- ") && len(fields) > 2:
+			name = fields[2]
+			results[name] = fields[1]
+		case name != "" && strings.HasPrefix(line, "    "):
+			logs[name] = append(logs[name], trimmed)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		result string
+		logged string
+	}{
+		{"TestSuiteTest/tests.ac.json/pass", "PASS:", ""},
+		{"TestSuiteTest/tests.ac.json/fail", "FAIL:", "404"},
+		{"TestSuiteTest/tests.ac.json/skip", "SKIP:", "not ready"},
+	}
+
+	for _, test := range tests {
+		result, ok := results[test.name]
+		if !ok {
+			t.Errorf("Expected subtest %v to be run\n%s", test.name, out)
+			continue
+		}
+
+		if result != test.result {
+			t.Errorf("Expected subtest %v to %v but received %v", test.name, test.result, result)
+		}
+
+		logged := strings.Join(logs[test.name], "\n")
+		if test.logged == "" && logged != "" {
+			t.Errorf("Expected subtest %v to log nothing but received %q", test.name, logged)
+		} else if !strings.Contains(logged, test.logged) {
+			t.Errorf("Expected subtest %v to log %q but received %q", test.name, test.logged, logged)
+		}
+	}
+
+	// Parallel subtests must finish before the after all hook runs.
+	if !strings.Contains(string(out), "after all: 3 tests done") {
+		t.Errorf("Expected every test to finish before the after all hook\n%s", out)
+	}
+}